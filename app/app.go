@@ -152,6 +152,9 @@ type App struct {
 
 	encodingConfig encoding.Config
 
+	// snapshotScheduler creates state sync snapshots if a snapshot schedule is set.
+	snapshotScheduler *snapshotScheduler
+
 	// keys to access the substores
 	keys    map[string]*storetypes.KVStoreKey
 	tkeys   map[string]*storetypes.TransientStoreKey
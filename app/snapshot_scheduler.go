@@ -0,0 +1,467 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"cosmossdk.io/log"
+	snapshottypes "cosmossdk.io/store/snapshots/types"
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/server"
+	servertypes "github.com/cosmos/cosmos-sdk/server/types"
+	"github.com/spf13/cast"
+)
+
+const (
+	// FlagSnapshotWindow restricts state sync snapshot creation to a daily UTC
+	// time window formatted as "HH:MM-HH:MM". The window may wrap around
+	// midnight.
+	FlagSnapshotWindow = "state-sync.snapshot-window"
+
+	// FlagSnapshotMinSpacing is the minimum wall-clock time between the start
+	// of two consecutive state sync snapshots.
+	FlagSnapshotMinSpacing = "state-sync.snapshot-min-spacing"
+
+	// FlagSnapshotMaxPending is the maximum number of snapshot heights waiting
+	// to be snapshotted. When exceeded, the oldest pending height is dropped.
+	FlagSnapshotMaxPending = "state-sync.snapshot-max-pending"
+
+	// defaultSnapshotMaxPending is the default value of FlagSnapshotMaxPending.
+	defaultSnapshotMaxPending = 1
+
+	// snapshotScheduleStateFile is the file in the data directory the pending
+	// snapshot heights are persisted to.
+	snapshotScheduleStateFile = "snapshot_schedule.json"
+)
+
+// SnapshotSchedule configures when state sync snapshots are created. By
+// default snapshots are created by the Cosmos SDK as soon as a snapshot height
+// is committed. If a window or a minimum spacing is configured, snapshot
+// heights are retained and snapshotted once the schedule allows it, so that
+// snapshot creation doesn't compete with block processing at busy times.
+//
+// At most one snapshot is created at a time and snapshot IO is not throttled:
+// the Cosmos SDK snapshot manager rejects concurrent snapshot operations and
+// writes snapshot chunks internally without a hook to rate limit them.
+type SnapshotSchedule struct {
+	// Interval is the snapshot interval in blocks.
+	Interval uint64
+	// Window is the daily UTC time window in which snapshots may be created.
+	// A nil window allows snapshots at any time.
+	Window *TimeWindow
+	// MinSpacing is the minimum time between the start of two snapshots.
+	MinSpacing time.Duration
+	// MaxPending is the maximum number of snapshot heights waiting to be snapshotted.
+	MaxPending int
+	// StateFile is the file the pending snapshot heights are persisted to, so
+	// that they are snapshotted or released after a restart.
+	StateFile string
+}
+
+// ParseSnapshotSchedule reads the snapshot schedule from the app options.
+func ParseSnapshotSchedule(appOpts servertypes.AppOptions) (SnapshotSchedule, error) {
+	schedule := SnapshotSchedule{
+		Interval:   cast.ToUint64(appOpts.Get(server.FlagStateSyncSnapshotInterval)),
+		MinSpacing: cast.ToDuration(appOpts.Get(FlagSnapshotMinSpacing)),
+		MaxPending: cast.ToInt(appOpts.Get(FlagSnapshotMaxPending)),
+		StateFile:  filepath.Join(cast.ToString(appOpts.Get(flags.FlagHome)), "data", snapshotScheduleStateFile),
+	}
+
+	if schedule.MinSpacing < 0 {
+		return schedule, fmt.Errorf("%s must not be negative", FlagSnapshotMinSpacing)
+	}
+	if schedule.MaxPending < 0 {
+		return schedule, fmt.Errorf("%s must not be negative", FlagSnapshotMaxPending)
+	}
+	if schedule.MaxPending == 0 {
+		schedule.MaxPending = defaultSnapshotMaxPending
+	}
+
+	if window := cast.ToString(appOpts.Get(FlagSnapshotWindow)); window != "" {
+		w, err := ParseTimeWindow(window)
+		if err != nil {
+			return schedule, fmt.Errorf("invalid %s: %w", FlagSnapshotWindow, err)
+		}
+		schedule.Window = &w
+	}
+
+	return schedule, nil
+}
+
+// Enabled returns true if snapshot creation is scheduled by the app instead of
+// the Cosmos SDK.
+func (s SnapshotSchedule) Enabled() bool {
+	return s.Interval > 0 && (s.Window != nil || s.MinSpacing > 0)
+}
+
+// AppOptions returns the app options to construct the base app with. If the
+// schedule is enabled, the snapshot interval is hidden from the base app so
+// that the Cosmos SDK does not create snapshots on its own.
+func (s SnapshotSchedule) AppOptions(appOpts servertypes.AppOptions) servertypes.AppOptions {
+	if !s.Enabled() {
+		return appOpts
+	}
+	return withoutSnapshotInterval{appOpts}
+}
+
+type withoutSnapshotInterval struct {
+	servertypes.AppOptions
+}
+
+func (o withoutSnapshotInterval) Get(key string) interface{} {
+	if key == server.FlagStateSyncSnapshotInterval {
+		return uint64(0)
+	}
+	return o.AppOptions.Get(key)
+}
+
+// TimeWindow is a daily time window in UTC.
+type TimeWindow struct {
+	// Start and End are offsets since midnight.
+	Start, End time.Duration
+}
+
+// ParseTimeWindow parses a window formatted as "HH:MM-HH:MM".
+func ParseTimeWindow(s string) (TimeWindow, error) {
+	start, end, found := strings.Cut(s, "-")
+	if !found {
+		return TimeWindow{}, fmt.Errorf("time window %q must be formatted as HH:MM-HH:MM", s)
+	}
+
+	startOffset, err := parseTimeOfDay(start)
+	if err != nil {
+		return TimeWindow{}, err
+	}
+	endOffset, err := parseTimeOfDay(end)
+	if err != nil {
+		return TimeWindow{}, err
+	}
+	if startOffset == endOffset {
+		return TimeWindow{}, fmt.Errorf("time window %q must not be empty", s)
+	}
+
+	return TimeWindow{Start: startOffset, End: endOffset}, nil
+}
+
+// Contains returns true if the time of day of t in UTC is inside the window.
+func (w TimeWindow) Contains(t time.Time) bool {
+	t = t.UTC()
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	if w.Start < w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	// the window wraps around midnight
+	return offset >= w.Start || offset < w.End
+}
+
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q: %w", s, err)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// SetSnapshotSchedule makes the app create state sync snapshots according to
+// the schedule. The base app must have been constructed with the app options
+// returned by schedule.AppOptions. Snapshot heights that were pending when the
+// node stopped are queued again. If the schedule is not enabled, heights left
+// pending by a previous schedule are released so that they don't block pruning.
+func (app *App) SetSnapshotSchedule(schedule SnapshotSchedule) error {
+	if app.SnapshotManager() == nil {
+		return nil
+	}
+
+	scheduler := newSnapshotScheduler(schedule, app.SnapshotManager(), app.CommitMultiStore(), app.Logger())
+	if !schedule.Enabled() {
+		return scheduler.releasePending()
+	}
+
+	if err := scheduler.restore(uint64(app.LastBlockHeight())); err != nil {
+		return err
+	}
+	app.snapshotScheduler = scheduler
+	return nil
+}
+
+// Commit overrides the default Commit method to create state sync snapshots
+// according to the snapshot schedule.
+func (app *App) Commit() (*abci.ResponseCommit, error) {
+	resp, err := app.BaseApp.Commit()
+	if err != nil || app.snapshotScheduler == nil {
+		return resp, err
+	}
+
+	app.snapshotScheduler.onCommit(app.LastBlockHeight(), time.Now())
+	return resp, nil
+}
+
+// snapshotCreator creates, lists and prunes state sync snapshots. It is
+// implemented by the Cosmos SDK snapshot manager.
+type snapshotCreator interface {
+	Create(height uint64) (*snapshottypes.Snapshot, error)
+	List() ([]*snapshottypes.Snapshot, error)
+	Prune(retain uint32) (uint64, error)
+	GetKeepRecent() uint32
+}
+
+// snapshotScheduleState is the persisted state of a snapshotScheduler.
+type snapshotScheduleState struct {
+	// Pending are the snapshot heights that were neither snapshotted nor
+	// released, including a snapshot in progress.
+	Pending []uint64 `json:"pending"`
+	// LastHeight is the last snapshot height that was queued.
+	LastHeight uint64 `json:"last_height"`
+}
+
+// snapshotScheduler creates state sync snapshots according to a SnapshotSchedule.
+//
+// Every snapshot height is retained by the store's pruning manager until it is
+// released via Create or PruneSnapshotHeight. The pruning manager only prunes
+// up to the first unreleased height and a height must not be released twice,
+// so the pending heights are persisted and released exactly once across
+// restarts.
+type snapshotScheduler struct {
+	schedule SnapshotSchedule
+	creator  snapshotCreator
+	// store releases retained heights that won't be snapshotted.
+	store  snapshottypes.Snapshotter
+	logger log.Logger
+
+	mu         sync.Mutex
+	pending    []uint64
+	inProgress uint64
+	lastHeight uint64
+	running    bool
+	lastStart  time.Time
+}
+
+func newSnapshotScheduler(schedule SnapshotSchedule, creator snapshotCreator, store snapshottypes.Snapshotter, logger log.Logger) *snapshotScheduler {
+	return &snapshotScheduler{
+		schedule: schedule,
+		creator:  creator,
+		store:    store,
+		logger:   logger.With("module", "snapshot-scheduler"),
+	}
+}
+
+// restore queues the snapshot heights that were pending when the node stopped
+// and the snapshot heights up to lastBlockHeight that were committed but never
+// queued, e.g. because the node crashed right after committing them.
+func (s *snapshotScheduler) restore(lastBlockHeight uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// retain snapshot heights until they are snapshotted or dropped.
+	s.store.SetSnapshotInterval(s.schedule.Interval)
+
+	state, found, err := s.loadState()
+	if err != nil {
+		return err
+	}
+	if !found {
+		// previously committed snapshot heights were handled by the Cosmos SDK.
+		s.lastHeight = lastBlockHeight
+		return s.saveStateLocked()
+	}
+
+	heights := state.Pending
+	for height := state.LastHeight + 1; height <= lastBlockHeight; height++ {
+		if height%s.schedule.Interval == 0 {
+			heights = append(heights, height)
+		}
+	}
+	heights, err = s.withoutSnapshots(heights)
+	if err != nil {
+		return err
+	}
+
+	s.lastHeight = max(state.LastHeight, lastBlockHeight)
+	for _, height := range heights {
+		s.logger.Info("restoring pending snapshot height", "height", height)
+		s.enqueueLocked(height)
+	}
+	return s.saveStateLocked()
+}
+
+// releasePending releases the snapshot heights left pending by a previous
+// snapshot schedule and removes the persisted state.
+func (s *snapshotScheduler) releasePending() error {
+	state, found, err := s.loadState()
+	if err != nil || !found {
+		return err
+	}
+
+	heights, err := s.withoutSnapshots(state.Pending)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(s.schedule.StateFile); err != nil {
+		return err
+	}
+	for _, height := range heights {
+		s.logger.Info("releasing pending snapshot height", "height", height)
+		s.store.PruneSnapshotHeight(int64(height))
+	}
+	return nil
+}
+
+// withoutSnapshots returns the heights for which no snapshot exists. A height
+// that was snapshotted has already been released.
+func (s *snapshotScheduler) withoutSnapshots(heights []uint64) ([]uint64, error) {
+	snapshots, err := s.creator.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	result := make([]uint64, 0, len(heights))
+	for _, height := range heights {
+		if slices.ContainsFunc(snapshots, func(snapshot *snapshottypes.Snapshot) bool { return snapshot.Height == height }) {
+			continue
+		}
+		if !slices.Contains(result, height) {
+			result = append(result, height)
+		}
+	}
+	slices.Sort(result)
+	return result, nil
+}
+
+// onCommit queues the committed height if it is a snapshot height and starts
+// the next pending snapshot if the schedule allows it.
+func (s *snapshotScheduler) onCommit(height int64, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changed := false
+	if height > 0 && uint64(height)%s.schedule.Interval == 0 && uint64(height) > s.lastHeight {
+		s.lastHeight = uint64(height)
+		s.enqueueLocked(uint64(height))
+		changed = true
+	}
+
+	if s.canStartLocked(now) {
+		next := s.pending[0]
+		s.pending = s.pending[1:]
+		s.inProgress = next
+		s.running = true
+		s.lastStart = now
+		go s.snapshot(next)
+		changed = true
+	}
+
+	if changed {
+		if err := s.saveStateLocked(); err != nil {
+			s.logger.Error("failed to persist pending snapshot heights", "err", err)
+		}
+	}
+}
+
+// enqueueLocked queues height and drops the oldest pending height if more than
+// MaxPending heights are pending. The dropped height is persisted as no longer
+// pending before it is released, so that it is never released twice.
+func (s *snapshotScheduler) enqueueLocked(height uint64) {
+	s.pending = append(s.pending, height)
+	if len(s.pending) <= s.schedule.MaxPending {
+		return
+	}
+
+	dropped := s.pending[0]
+	s.pending = s.pending[1:]
+	if err := s.saveStateLocked(); err != nil {
+		s.logger.Error("failed to persist pending snapshot heights", "err", err)
+	}
+	s.logger.Info("dropping pending snapshot height", "height", dropped)
+	s.store.PruneSnapshotHeight(int64(dropped))
+}
+
+// canStartLocked returns true if a pending snapshot can be started at now.
+func (s *snapshotScheduler) canStartLocked(now time.Time) bool {
+	if s.running || len(s.pending) == 0 {
+		return false
+	}
+	if s.schedule.Window != nil && !s.schedule.Window.Contains(now) {
+		return false
+	}
+	if !s.lastStart.IsZero() && now.Sub(s.lastStart) < s.schedule.MinSpacing {
+		return false
+	}
+	return true
+}
+
+func (s *snapshotScheduler) snapshot(height uint64) {
+	defer func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.running = false
+		s.inProgress = 0
+		// Create releases the height whether or not it succeeds.
+		if err := s.saveStateLocked(); err != nil {
+			s.logger.Error("failed to persist pending snapshot heights", "err", err)
+		}
+	}()
+
+	s.logger.Info("creating state snapshot", "height", height)
+	snapshot, err := s.creator.Create(height)
+	if err != nil {
+		s.logger.Error("failed to create state snapshot", "height", height, "err", err)
+		return
+	}
+	s.logger.Info("completed state snapshot", "height", height, "format", snapshot.Format)
+
+	if keepRecent := s.creator.GetKeepRecent(); keepRecent > 0 {
+		pruned, err := s.creator.Prune(keepRecent)
+		if err != nil {
+			s.logger.Error("failed to prune state snapshots", "err", err)
+			return
+		}
+		s.logger.Debug("pruned state snapshots", "pruned", pruned)
+	}
+}
+
+// loadState loads the persisted scheduler state. found is false if no state
+// was persisted.
+func (s *snapshotScheduler) loadState() (state snapshotScheduleState, found bool, err error) {
+	bz, err := os.ReadFile(s.schedule.StateFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, false, nil
+	}
+	if err != nil {
+		return state, false, fmt.Errorf("failed to read snapshot schedule state: %w", err)
+	}
+	if err := json.Unmarshal(bz, &state); err != nil {
+		return state, false, fmt.Errorf("failed to decode snapshot schedule state %s: %w", s.schedule.StateFile, err)
+	}
+	return state, true, nil
+}
+
+// saveStateLocked atomically persists the pending heights, including the
+// snapshot in progress.
+func (s *snapshotScheduler) saveStateLocked() error {
+	state := snapshotScheduleState{LastHeight: s.lastHeight}
+	if s.inProgress != 0 {
+		state.Pending = append(state.Pending, s.inProgress)
+	}
+	state.Pending = append(state.Pending, s.pending...)
+
+	bz, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.schedule.StateFile), 0o755); err != nil {
+		return err
+	}
+	tmp := s.schedule.StateFile + ".tmp"
+	if err := os.WriteFile(tmp, bz, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.schedule.StateFile)
+}
@@ -0,0 +1,210 @@
+package app
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"cosmossdk.io/log"
+	snapshottypes "cosmossdk.io/store/snapshots/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimeWindow(t *testing.T) {
+	tests := []struct {
+		name    string
+		window  string
+		inside  []string
+		outside []string
+		wantErr bool
+	}{
+		{
+			name:    "same day window",
+			window:  "01:00-05:30",
+			inside:  []string{"01:00", "03:00", "05:29"},
+			outside: []string{"00:59", "05:30", "23:00"},
+		},
+		{
+			name:    "window wrapping around midnight",
+			window:  "22:00-02:00",
+			inside:  []string{"22:00", "23:59", "00:00", "01:59"},
+			outside: []string{"02:00", "12:00", "21:59"},
+		},
+		{name: "missing separator", window: "01:00", wantErr: true},
+		{name: "invalid time", window: "25:00-02:00", wantErr: true},
+		{name: "empty window", window: "02:00-02:00", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w, err := ParseTimeWindow(tc.window)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			for _, s := range tc.inside {
+				require.True(t, w.Contains(timeOfDay(t, s)), s)
+			}
+			for _, s := range tc.outside {
+				require.False(t, w.Contains(timeOfDay(t, s)), s)
+			}
+		})
+	}
+}
+
+func TestSnapshotScheduler(t *testing.T) {
+	window, err := ParseTimeWindow("01:00-02:00")
+	require.NoError(t, err)
+
+	creator := &mockSnapshotCreator{done: make(chan uint64, 10)}
+	store := &mockSnapshotter{}
+	schedule := SnapshotSchedule{Interval: 10, Window: &window, MaxPending: 2, StateFile: filepath.Join(t.TempDir(), "state.json")}
+	s := newSnapshotScheduler(schedule, creator, store, log.NewNopLogger())
+	require.NoError(t, s.restore(0))
+	require.Equal(t, uint64(10), store.interval)
+
+	// snapshot heights outside of the window are retained
+	s.onCommit(10, timeOfDay(t, "00:30"))
+	s.onCommit(15, timeOfDay(t, "00:31"))
+	s.onCommit(20, timeOfDay(t, "00:32"))
+	require.Empty(t, creator.created())
+
+	// the oldest pending height is dropped once max pending is exceeded
+	s.onCommit(30, timeOfDay(t, "00:33"))
+	require.Equal(t, []int64{10}, store.prunedHeights())
+
+	// the oldest pending snapshot is created once the window opens
+	s.onCommit(31, timeOfDay(t, "01:00"))
+	require.Equal(t, uint64(20), <-creator.done)
+	waitForSnapshot(t, s)
+
+	s.onCommit(32, timeOfDay(t, "01:01"))
+	require.Equal(t, uint64(30), <-creator.done)
+	require.Equal(t, []uint64{20, 30}, creator.created())
+}
+
+func TestSnapshotSchedulerRestart(t *testing.T) {
+	window, err := ParseTimeWindow("01:00-02:00")
+	require.NoError(t, err)
+
+	creator := &mockSnapshotCreator{done: make(chan uint64, 10)}
+	store := &mockSnapshotter{}
+	schedule := SnapshotSchedule{Interval: 10, Window: &window, MaxPending: 3, StateFile: filepath.Join(t.TempDir(), "state.json")}
+
+	s := newSnapshotScheduler(schedule, creator, store, log.NewNopLogger())
+	require.NoError(t, s.restore(5))
+	s.onCommit(10, timeOfDay(t, "00:30"))
+	s.onCommit(20, timeOfDay(t, "00:31"))
+	require.Empty(t, creator.created())
+
+	// the node restarts at height 30, which was committed but never queued
+	s = newSnapshotScheduler(schedule, creator, store, log.NewNopLogger())
+	require.NoError(t, s.restore(30))
+	s.mu.Lock()
+	require.Equal(t, []uint64{10, 20, 30}, s.pending)
+	s.mu.Unlock()
+
+	// heights are queued once, so the oldest one is dropped exactly once
+	s.onCommit(40, timeOfDay(t, "00:32"))
+	require.Equal(t, []int64{10}, store.prunedHeights())
+
+	// a completed snapshot is not queued again after a restart
+	s.onCommit(41, timeOfDay(t, "01:00"))
+	require.Equal(t, uint64(20), <-creator.done)
+	waitForSnapshot(t, s)
+
+	s = newSnapshotScheduler(schedule, creator, store, log.NewNopLogger())
+	require.NoError(t, s.restore(41))
+	s.mu.Lock()
+	require.Equal(t, []uint64{30, 40}, s.pending)
+	s.mu.Unlock()
+	require.Equal(t, []int64{10}, store.prunedHeights())
+
+	// disabling the schedule releases the pending heights
+	disabled := newSnapshotScheduler(SnapshotSchedule{Interval: 10, StateFile: schedule.StateFile}, creator, store, log.NewNopLogger())
+	require.NoError(t, disabled.releasePending())
+	require.Equal(t, []int64{10, 30, 40}, store.prunedHeights())
+	require.NoFileExists(t, schedule.StateFile)
+}
+
+func waitForSnapshot(t *testing.T, s *snapshotScheduler) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return !s.running
+	}, time.Second, time.Millisecond)
+}
+
+func TestSnapshotScheduleEnabled(t *testing.T) {
+	window, err := ParseTimeWindow("01:00-02:00")
+	require.NoError(t, err)
+
+	require.False(t, SnapshotSchedule{Interval: 10}.Enabled())
+	require.False(t, SnapshotSchedule{Window: &window}.Enabled())
+	require.True(t, SnapshotSchedule{Interval: 10, Window: &window}.Enabled())
+	require.True(t, SnapshotSchedule{Interval: 10, MinSpacing: time.Hour}.Enabled())
+}
+
+func timeOfDay(t *testing.T, s string) time.Time {
+	t.Helper()
+	tod, err := time.Parse("15:04", s)
+	require.NoError(t, err)
+	return time.Date(2025, 1, 1, tod.Hour(), tod.Minute(), 0, 0, time.UTC)
+}
+
+type mockSnapshotCreator struct {
+	mu      sync.Mutex
+	heights []uint64
+	done    chan uint64
+}
+
+func (m *mockSnapshotCreator) Create(height uint64) (*snapshottypes.Snapshot, error) {
+	m.mu.Lock()
+	m.heights = append(m.heights, height)
+	m.mu.Unlock()
+	m.done <- height
+	return &snapshottypes.Snapshot{Height: height}, nil
+}
+
+func (m *mockSnapshotCreator) List() ([]*snapshottypes.Snapshot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snapshots := make([]*snapshottypes.Snapshot, 0, len(m.heights))
+	for _, height := range m.heights {
+		snapshots = append(snapshots, &snapshottypes.Snapshot{Height: height})
+	}
+	return snapshots, nil
+}
+
+func (m *mockSnapshotCreator) Prune(uint32) (uint64, error) { return 0, nil }
+
+func (m *mockSnapshotCreator) GetKeepRecent() uint32 { return 2 }
+
+func (m *mockSnapshotCreator) created() []uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]uint64(nil), m.heights...)
+}
+
+type mockSnapshotter struct {
+	snapshottypes.Snapshotter
+	mu       sync.Mutex
+	interval uint64
+	pruned   []int64
+}
+
+func (m *mockSnapshotter) SetSnapshotInterval(interval uint64) { m.interval = interval }
+
+func (m *mockSnapshotter) PruneSnapshotHeight(height int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pruned = append(m.pruned, height)
+}
+
+func (m *mockSnapshotter) prunedHeights() []int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]int64(nil), m.pruned...)
+}
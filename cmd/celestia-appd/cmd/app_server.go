@@ -13,12 +13,21 @@ import (
 )
 
 func NewAppServer(logger log.Logger, db dbm.DB, traceStore io.Writer, appOptions servertypes.AppOptions) servertypes.Application {
-	return app.New(
+	snapshotSchedule, err := app.ParseSnapshotSchedule(appOptions)
+	if err != nil {
+		panic(err)
+	}
+
+	application := app.New(
 		logger,
 		db,
 		traceStore,
 		cast.ToDuration(appOptions.Get(TimeoutCommitFlag)),
 		appOptions,
-		server.DefaultBaseappOptions(appOptions)...,
+		server.DefaultBaseappOptions(snapshotSchedule.AppOptions(appOptions))...,
 	)
+	if err := application.SetSnapshotSchedule(snapshotSchedule); err != nil {
+		panic(err)
+	}
+	return application
 }
@@ -0,0 +1,300 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"cosmossdk.io/log"
+	"cosmossdk.io/store/snapshots"
+	snapshottypes "cosmossdk.io/store/snapshots/types"
+	dbm "github.com/cosmos/cosmos-db"
+	"github.com/cosmos/cosmos-sdk/baseapp"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/server"
+	servertypes "github.com/cosmos/cosmos-sdk/server/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	genutiltypes "github.com/cosmos/cosmos-sdk/x/genutil/types"
+	"github.com/spf13/cobra"
+
+	"github.com/celestiaorg/celestia-app/v4/app"
+)
+
+// FlagFromSnapshot makes the export command export the state of the latest
+// local state sync snapshot instead of the application database. Since the
+// application database isn't opened, the node can keep running.
+const FlagFromSnapshot = "from-snapshot"
+
+// addExportFromSnapshot adds the --from-snapshot flag to the export command.
+func addExportFromSnapshot(exportCmd *cobra.Command) {
+	exportCmd.Flags().Bool(FlagFromSnapshot, false, "Export the state of the latest local snapshot (or the snapshot at --height) instead of the application database. Can be used while the node is running")
+
+	exportCmdRunE := exportCmd.RunE
+	exportCmd.RunE = func(cmd *cobra.Command, args []string) error {
+		fromSnapshot, err := cmd.Flags().GetBool(FlagFromSnapshot)
+		if err != nil {
+			return err
+		}
+		if !fromSnapshot {
+			return exportCmdRunE(cmd, args)
+		}
+		return exportFromSnapshot(cmd)
+	}
+}
+
+// exportFromSnapshot restores a local snapshot into a temporary application
+// database and exports the genesis from it.
+func exportFromSnapshot(cmd *cobra.Command) error {
+	svrCtx := server.GetServerContextFromCmd(cmd)
+	cfg := svrCtx.Config
+
+	height, err := cmd.Flags().GetInt64(server.FlagHeight)
+	if err != nil {
+		return err
+	}
+	forZeroHeight, err := cmd.Flags().GetBool(server.FlagForZeroHeight)
+	if err != nil {
+		return err
+	}
+	jailAllowedAddrs, err := cmd.Flags().GetStringSlice(server.FlagJailAllowedAddrs)
+	if err != nil {
+		return err
+	}
+	modulesToExport, err := cmd.Flags().GetStringSlice(server.FlagModulesToExport)
+	if err != nil {
+		return err
+	}
+	outputDocument, err := cmd.Flags().GetString(flags.FlagOutputDocument)
+	if err != nil {
+		return err
+	}
+
+	appGenesis, err := genutiltypes.AppGenesisFromFile(cfg.GenesisFile())
+	if err != nil {
+		return err
+	}
+
+	// the temporary directory is created next to the data directory as
+	// restoring a snapshot needs about as much space as the application database.
+	tmpDir, err := os.MkdirTemp(cfg.RootDir, "export-snapshot-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	backend := server.GetAppDBBackend(svrCtx.Viper)
+	snapshot, err := restoreSnapshot(svrCtx.Logger, filepath.Join(cfg.DBDir(), "snapshots"), tmpDir, backend, height, svrCtx.Viper)
+	if err != nil {
+		return err
+	}
+	svrCtx.Logger.Info("restored snapshot for export", "height", snapshot.Height, "format", snapshot.Format)
+
+	db, err := dbm.NewDB("application", backend, tmpDir)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	exported, err := appExporter(svrCtx.Logger, db, nil, -1, forZeroHeight, jailAllowedAddrs, svrCtx.Viper, modulesToExport)
+	if err != nil {
+		return fmt.Errorf("error exporting state: %w", err)
+	}
+
+	appGenesis.AppState = exported.AppState
+	appGenesis.InitialHeight = exported.Height
+	appGenesis.Consensus = genutiltypes.NewConsensusGenesis(exported.ConsensusParams, exported.Validators)
+
+	if outputDocument == "" {
+		out, err := json.Marshal(appGenesis)
+		if err != nil {
+			return err
+		}
+		out, err = sdk.SortJSON(out)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(cmd.OutOrStdout(), string(out))
+		return err
+	}
+
+	return appGenesis.SaveAs(outputDocument)
+}
+
+// restoreSnapshot copies the snapshot at height, or the latest snapshot
+// if height is -1, from snapshotDir into tmpDir and restores it into a new
+// application database in tmpDir. The snapshot is copied first so that the
+// running node can keep creating and pruning snapshots in the meantime.
+func restoreSnapshot(
+	logger log.Logger,
+	snapshotDir, tmpDir string,
+	backend dbm.BackendType,
+	height int64,
+	appOpts servertypes.AppOptions,
+) (*snapshottypes.Snapshot, error) {
+	tmpSnapshotDir := filepath.Join(tmpDir, "snapshots")
+	copied, err := copySnapshot(snapshotDir, tmpSnapshotDir, backend, height)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := dbm.NewDB("application", backend, tmpDir)
+	if err != nil {
+		return nil, err
+	}
+
+	application := app.New(logger, db, nil, 0, appOpts,
+		baseapp.SetSnapshot(copied.store, snapshottypes.NewSnapshotOptions(0, 0)),
+	)
+	restoreErr := application.SnapshotManager().RestoreLocalSnapshot(copied.snapshot.Height, copied.snapshot.Format)
+	// Close also closes the application and snapshot metadata databases.
+	if err := application.Close(); err != nil && restoreErr == nil {
+		restoreErr = err
+	}
+	if restoreErr != nil {
+		return nil, fmt.Errorf("failed to restore snapshot at height %d: %w", copied.snapshot.Height, restoreErr)
+	}
+
+	return copied.snapshot, nil
+}
+
+// copiedSnapshot is a snapshot copied into a standalone snapshot store.
+type copiedSnapshot struct {
+	store    *snapshots.Store
+	snapshot *snapshottypes.Snapshot
+}
+
+// copySnapshotAttempts is the number of times copySnapshot tries to copy a
+// snapshot. The running node may compact the metadata database or prune the
+// snapshot while it is being copied, in which case the copy is retried.
+const copySnapshotAttempts = 3
+
+// copySnapshotRetryDelay is the time copySnapshot waits before retrying.
+const copySnapshotRetryDelay = 100 * time.Millisecond
+
+// errNoLocalSnapshot is returned by copySnapshot if the requested snapshot
+// does not exist. It is not retried.
+var errNoLocalSnapshot = errors.New("no local snapshot found")
+
+// copySnapshot copies the snapshot metadata database and the chunks of the
+// snapshot at height, or of the latest snapshot if height is -1, from srcDir
+// into dstDir. The files are copied while the node may be creating or pruning
+// snapshots, so the copied chunks are verified against the chunk hashes in the
+// copied metadata and the copy is retried if files disappear or don't match.
+func copySnapshot(srcDir, dstDir string, backend dbm.BackendType, height int64) (copiedSnapshot, error) {
+	var err error
+	for attempt := 1; attempt <= copySnapshotAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(copySnapshotRetryDelay)
+		}
+		if err = os.RemoveAll(dstDir); err != nil {
+			return copiedSnapshot{}, err
+		}
+
+		var copied copiedSnapshot
+		copied, err = copySnapshotOnce(srcDir, dstDir, backend, height)
+		if err == nil || errors.Is(err, errNoLocalSnapshot) {
+			return copied, err
+		}
+	}
+	return copiedSnapshot{}, fmt.Errorf("failed to copy snapshot after %d attempts: %w", copySnapshotAttempts, err)
+}
+
+// copySnapshotOnce makes a single attempt at copying and verifying a snapshot.
+func copySnapshotOnce(srcDir, dstDir string, backend dbm.BackendType, height int64) (copiedSnapshot, error) {
+	if err := copyDir(filepath.Join(srcDir, "metadata.db"), filepath.Join(dstDir, "metadata.db")); err != nil {
+		return copiedSnapshot{}, fmt.Errorf("failed to copy snapshot metadata: %w", err)
+	}
+
+	metadata, err := dbm.NewDB("metadata", backend, dstDir)
+	if err != nil {
+		return copiedSnapshot{}, err
+	}
+	store, err := snapshots.NewStore(metadata, dstDir)
+	if err != nil {
+		metadata.Close()
+		return copiedSnapshot{}, err
+	}
+
+	var snapshot *snapshottypes.Snapshot
+	if height == -1 {
+		snapshot, err = store.GetLatest()
+	} else {
+		snapshot, err = store.Get(uint64(height), snapshottypes.CurrentFormat)
+	}
+	if err == nil && snapshot == nil {
+		err = fmt.Errorf("%w in %s", errNoLocalSnapshot, srcDir)
+	}
+	if err != nil {
+		metadata.Close()
+		return copiedSnapshot{}, err
+	}
+
+	chunkDir := filepath.Join(strconv.FormatUint(snapshot.Height, 10), strconv.FormatUint(uint64(snapshot.Format), 10))
+	if err := copyDir(filepath.Join(srcDir, chunkDir), filepath.Join(dstDir, chunkDir)); err != nil {
+		metadata.Close()
+		return copiedSnapshot{}, fmt.Errorf("failed to copy snapshot chunks at height %d: %w", snapshot.Height, err)
+	}
+
+	if err := verifySnapshotChunks(store, snapshot); err != nil {
+		metadata.Close()
+		return copiedSnapshot{}, err
+	}
+
+	return copiedSnapshot{store: store, snapshot: snapshot}, nil
+}
+
+// verifySnapshotChunks checks that every chunk of the snapshot exists in the
+// store and matches the chunk hash recorded in the snapshot metadata.
+func verifySnapshotChunks(store *snapshots.Store, snapshot *snapshottypes.Snapshot) error {
+	if len(snapshot.Metadata.ChunkHashes) != int(snapshot.Chunks) {
+		return fmt.Errorf("snapshot at height %d has %d chunks but %d chunk hashes", snapshot.Height, snapshot.Chunks, len(snapshot.Metadata.ChunkHashes))
+	}
+
+	for i, want := range snapshot.Metadata.ChunkHashes {
+		chunk, err := store.LoadChunk(snapshot.Height, snapshot.Format, uint32(i))
+		if err != nil {
+			return err
+		}
+		if chunk == nil {
+			return fmt.Errorf("snapshot chunk %d at height %d is missing", i, snapshot.Height)
+		}
+
+		hasher := sha256.New()
+		_, err = io.Copy(hasher, chunk)
+		chunk.Close()
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(hasher.Sum(nil), want) {
+			return fmt.Errorf("snapshot chunk %d at height %d does not match its hash", i, snapshot.Height)
+		}
+	}
+	return nil
+}
+
+// copyDir recursively copies the files in srcDir into dstDir.
+func copyDir(srcDir, dstDir string) error {
+	return filepath.WalkDir(srcDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(dstDir, rel)
+
+		if d.IsDir() {
+			return os.MkdirAll(dst, 0o755)
+		}
+		return copyFile(path, dst)
+	})
+}
@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"cosmossdk.io/log"
+	"cosmossdk.io/store/snapshots"
+	snapshottypes "cosmossdk.io/store/snapshots/types"
+	abci "github.com/cometbft/cometbft/abci/types"
+	tmtypes "github.com/cometbft/cometbft/types"
+	dbm "github.com/cosmos/cosmos-db"
+	"github.com/cosmos/cosmos-sdk/baseapp"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/server"
+	genutiltypes "github.com/cosmos/cosmos-sdk/x/genutil/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/celestia-app/v4/app"
+	"github.com/celestiaorg/celestia-app/v4/test/util"
+)
+
+func TestCopySnapshot(t *testing.T) {
+	srcDir := filepath.Join(t.TempDir(), "snapshots")
+	saveSnapshot(t, srcDir, 10, []byte("chunk at 10"))
+	saveSnapshot(t, srcDir, 20, []byte("chunk at 20"))
+
+	t.Run("latest snapshot", func(t *testing.T) {
+		copied, err := copySnapshot(srcDir, filepath.Join(t.TempDir(), "snapshots"), dbm.GoLevelDBBackend, -1)
+		require.NoError(t, err)
+		require.Equal(t, uint64(20), copied.snapshot.Height)
+		requireChunk(t, copied.store, 20, []byte("chunk at 20"))
+	})
+
+	t.Run("snapshot at height", func(t *testing.T) {
+		copied, err := copySnapshot(srcDir, filepath.Join(t.TempDir(), "snapshots"), dbm.GoLevelDBBackend, 10)
+		require.NoError(t, err)
+		require.Equal(t, uint64(10), copied.snapshot.Height)
+		requireChunk(t, copied.store, 10, []byte("chunk at 10"))
+	})
+
+	t.Run("missing snapshot", func(t *testing.T) {
+		_, err := copySnapshot(srcDir, filepath.Join(t.TempDir(), "snapshots"), dbm.GoLevelDBBackend, 15)
+		require.ErrorIs(t, err, errNoLocalSnapshot)
+	})
+
+	t.Run("chunk does not match its hash", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "snapshots")
+		saveSnapshot(t, dir, 10, []byte("chunk at 10"))
+		chunkPath := filepath.Join(dir, "10", strconv.FormatUint(uint64(snapshottypes.CurrentFormat), 10), "0")
+		require.NoError(t, os.WriteFile(chunkPath, []byte("torn chunk"), 0o644))
+
+		_, err := copySnapshot(dir, filepath.Join(t.TempDir(), "snapshots"), dbm.GoLevelDBBackend, 10)
+		require.ErrorContains(t, err, "does not match its hash")
+	})
+}
+
+// TestExportFromSnapshot verifies that exporting a snapshot while the node
+// keeps committing blocks and creating and pruning snapshots results in the
+// same genesis as exporting the state at the snapshot height.
+func TestExportFromSnapshot(t *testing.T) {
+	home := t.TempDir()
+	dataDir := filepath.Join(home, "data")
+	snapshotDir := filepath.Join(dataDir, "snapshots")
+
+	db, err := dbm.NewDB("application", dbm.GoLevelDBBackend, dataDir)
+	require.NoError(t, err)
+	snapshotDB, err := dbm.NewDB("metadata", dbm.GoLevelDBBackend, snapshotDir)
+	require.NoError(t, err)
+	snapshotStore, err := snapshots.NewStore(snapshotDB, snapshotDir)
+	require.NoError(t, err)
+
+	testApp := app.New(log.NewNopLogger(), db, nil, 0, util.EmptyAppOptions{},
+		baseapp.SetChainID(util.ChainID),
+		baseapp.SetSnapshot(snapshotStore, snapshottypes.NewSnapshotOptions(0, 0)),
+	)
+	defer testApp.Close()
+
+	genesisState, valSet, _ := util.GenesisStateWithSingleValidator(testApp, "genesisAcc")
+	util.InitialiseTestAppWithGenesis(testApp, app.DefaultConsensusParams(), genesisState)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, commitBlock(testApp, valSet))
+	}
+
+	height := uint64(testApp.LastBlockHeight())
+	_, err = testApp.SnapshotManager().Create(height)
+	require.NoError(t, err)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(home, "config"), 0o755))
+	appGenesis := genutiltypes.NewAppGenesisWithVersion(util.ChainID, nil)
+	appGenesis.GenesisTime = util.GenesisTime
+	require.NoError(t, appGenesis.SaveAs(filepath.Join(home, "config", "genesis.json")))
+
+	exported, err := testApp.ExportAppStateAndValidators(false, nil, nil)
+	require.NoError(t, err)
+	appGenesis.AppState = exported.AppState
+	appGenesis.InitialHeight = exported.Height
+	appGenesis.Consensus = genutiltypes.NewConsensusGenesis(exported.ConsensusParams, exported.Validators)
+	wantFile := filepath.Join(t.TempDir(), "genesis.json")
+	require.NoError(t, appGenesis.SaveAs(wantFile))
+	want, err := os.ReadFile(wantFile)
+	require.NoError(t, err)
+
+	// keep the node running: commit blocks and create and prune snapshots
+	// while the snapshot is exported.
+	done := make(chan struct{})
+	churnErr := make(chan error, 1)
+	go func() {
+		churnErr <- func() error {
+			var previous uint64
+			for {
+				select {
+				case <-done:
+					return nil
+				default:
+				}
+
+				if err := commitBlock(testApp, valSet); err != nil {
+					return err
+				}
+				latest := uint64(testApp.LastBlockHeight())
+				if _, err := testApp.SnapshotManager().Create(latest); err != nil {
+					return err
+				}
+				if previous != 0 {
+					if err := snapshotStore.Delete(previous, snapshottypes.CurrentFormat); err != nil {
+						return err
+					}
+				}
+				previous = latest
+			}
+		}()
+	}()
+
+	got := runExport(t, home, "--"+FlagFromSnapshot, "--"+server.FlagHeight, strconv.FormatUint(height, 10))
+	close(done)
+	require.NoError(t, <-churnErr)
+	require.JSONEq(t, string(want), string(got))
+}
+
+// commitBlock finalizes and commits the next block.
+func commitBlock(testApp *app.App, valSet *tmtypes.ValidatorSet) error {
+	_, err := testApp.FinalizeBlock(&abci.RequestFinalizeBlock{
+		Time:               util.GenesisTime.Add(time.Duration(testApp.LastBlockHeight()+1) * time.Second),
+		Height:             testApp.LastBlockHeight() + 1,
+		Hash:               testApp.LastCommitID().Hash,
+		NextValidatorsHash: valSet.Hash(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = testApp.Commit()
+	return err
+}
+
+// runExport runs the export command against home and returns the exported
+// genesis.
+func runExport(t *testing.T, home string, args ...string) []byte {
+	t.Helper()
+	svrCtx := server.NewDefaultContext()
+	svrCtx.Config.SetRoot(home)
+
+	exportCmd := server.ExportCmd(appExporter, home)
+	addExportFromSnapshot(exportCmd)
+
+	outputDocument := filepath.Join(t.TempDir(), "genesis.json")
+	exportCmd.SetArgs(append([]string{"--" + flags.FlagOutputDocument, outputDocument}, args...))
+	ctx := context.WithValue(context.Background(), server.ServerContextKey, svrCtx)
+	require.NoError(t, exportCmd.ExecuteContext(ctx))
+
+	exported, err := os.ReadFile(outputDocument)
+	require.NoError(t, err)
+	return exported
+}
+
+func saveSnapshot(t *testing.T, dir string, height uint64, chunk []byte) {
+	t.Helper()
+	db, err := dbm.NewDB("metadata", dbm.GoLevelDBBackend, dir)
+	require.NoError(t, err)
+	defer db.Close()
+
+	store, err := snapshots.NewStore(db, dir)
+	require.NoError(t, err)
+
+	chunks := make(chan io.ReadCloser, 1)
+	chunks <- io.NopCloser(bytes.NewReader(chunk))
+	close(chunks)
+	_, err = store.Save(height, snapshottypes.CurrentFormat, chunks)
+	require.NoError(t, err)
+}
+
+func requireChunk(t *testing.T, store *snapshots.Store, height uint64, want []byte) {
+	t.Helper()
+	chunk, err := store.LoadChunk(height, snapshottypes.CurrentFormat, 0)
+	require.NoError(t, err)
+	defer chunk.Close()
+
+	got, err := io.ReadAll(chunk)
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	rpchttp "github.com/cometbft/cometbft/rpc/client/http"
+	"github.com/cosmos/cosmos-sdk/server"
+	"github.com/spf13/cobra"
+
+	"github.com/celestiaorg/celestia-app/v4/internal/health"
+)
+
+const (
+	// FlagHealthAddress is the address the /healthz and /readyz probes are
+	// served on. An empty value disables the probes.
+	FlagHealthAddress = "health.laddr"
+
+	// flagGRPCOnly mirrors the unexported Cosmos SDK start flag that runs the
+	// node without CometBFT.
+	flagGRPCOnly = "grpc-only"
+)
+
+// addHealthFlags adds the health probe flags to the start command.
+func addHealthFlags(startCmd *cobra.Command) {
+	startCmd.Flags().String(FlagHealthAddress, "", "Address to serve the /healthz and /readyz probes on (e.g. 0.0.0.0:8080). Disabled if empty")
+}
+
+// startHealthServer starts serving the health probes in the background if they
+// are configured. The node is reported as not ready while CometBFT is catching
+// up or state syncing. The CometBFT check is skipped if the node runs in
+// gRPC-only mode or the CometBFT RPC server is disabled. The returned function
+// stops the server.
+func startHealthServer(cmd *cobra.Command, healthServer *health.Server) (stop func(), err error) {
+	svrCtx := server.GetServerContextFromCmd(cmd)
+	address := svrCtx.Viper.GetString(FlagHealthAddress)
+	if address == "" {
+		return func() {}, nil
+	}
+
+	rpcAddress := svrCtx.Config.RPC.ListenAddress
+	if !svrCtx.Viper.GetBool(flagGRPCOnly) && rpcAddress != "" {
+		rpcClient, err := rpchttp.New(rpcAddress, "/websocket")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create comet rpc client for health checks: %w", err)
+		}
+		healthServer.AddCheck("comet", cometReadinessCheck(rpcClient))
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on health address %s: %w", address, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		if err := healthServer.Serve(ctx, listener); err != nil {
+			svrCtx.Logger.Error("health server stopped", "err", err)
+		}
+	}()
+	svrCtx.Logger.Info("started health server", "address", address)
+
+	return cancel, nil
+}
+
+// cometReadinessCheck returns a readiness check failing while the node is
+// catching up, which includes state syncing.
+func cometReadinessCheck(rpcClient *rpchttp.HTTP) func(context.Context) error {
+	return func(ctx context.Context) error {
+		status, err := rpcClient.Status(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get comet status: %w", err)
+		}
+		if status.SyncInfo.CatchingUp {
+			return errors.New("node is catching up")
+		}
+		return nil
+	}
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/server"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/celestia-app/v4/internal/health"
+)
+
+func TestStartHealthServer(t *testing.T) {
+	t.Run("skips the comet check in grpc-only mode", func(t *testing.T) {
+		cmd, svrCtx := healthTestCommand(t)
+		svrCtx.Viper.Set(FlagHealthAddress, "127.0.0.1:0")
+		svrCtx.Viper.Set(flagGRPCOnly, true)
+
+		healthServer := health.NewServer()
+		stop, err := startHealthServer(cmd, healthServer)
+		require.NoError(t, err)
+		defer stop()
+		require.NoError(t, healthServer.Ready(context.Background()))
+	})
+
+	t.Run("skips the comet check if the rpc server is disabled", func(t *testing.T) {
+		cmd, svrCtx := healthTestCommand(t)
+		svrCtx.Viper.Set(FlagHealthAddress, "127.0.0.1:0")
+		svrCtx.Config.RPC.ListenAddress = ""
+
+		healthServer := health.NewServer()
+		stop, err := startHealthServer(cmd, healthServer)
+		require.NoError(t, err)
+		defer stop()
+		require.NoError(t, healthServer.Ready(context.Background()))
+	})
+
+	t.Run("returns bind errors", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer listener.Close()
+
+		cmd, svrCtx := healthTestCommand(t)
+		svrCtx.Viper.Set(FlagHealthAddress, listener.Addr().String())
+		svrCtx.Viper.Set(flagGRPCOnly, true)
+
+		_, err = startHealthServer(cmd, health.NewServer())
+		require.Error(t, err)
+	})
+}
+
+func healthTestCommand(t *testing.T) (*cobra.Command, *server.Context) {
+	t.Helper()
+	svrCtx := server.NewDefaultContext()
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.WithValue(context.Background(), server.ServerContextKey, svrCtx))
+	return cmd, svrCtx
+}
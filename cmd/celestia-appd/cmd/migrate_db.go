@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	dbm "github.com/cosmos/cosmos-db"
+	"github.com/cosmos/cosmos-sdk/server"
+	"github.com/spf13/cobra"
+)
+
+const (
+	flagTargetBackend = "target-backend"
+	flagOutputDir     = "output-dir"
+
+	// migrateBatchSize is the number of key-value pairs written per batch.
+	migrateBatchSize = 10_000
+)
+
+func migrateDBCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate-db",
+		Short: "Migrate the application and block stores to a different database backend",
+		Long: "Migrate the application and block stores to a different database backend.\n" +
+			"Every database in the data directory is copied into the output directory using the target backend. All other files (e.g. priv_validator_state.json, snapshots) are copied as is.\n" +
+			"The node must be stopped while migrating. Once done, replace the data directory with the output directory and set `db_backend` in config.toml and `app-db-backend` in app.toml to the target backend.\n",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			svrCtx := server.GetServerContextFromCmd(cmd)
+			cfg := svrCtx.Config
+
+			targetBackend, err := cmd.Flags().GetString(flagTargetBackend)
+			if err != nil {
+				return err
+			}
+
+			outputDir, err := cmd.Flags().GetString(flagOutputDir)
+			if err != nil {
+				return err
+			}
+			if outputDir == "" {
+				outputDir = filepath.Join(cfg.RootDir, "data-"+targetBackend)
+			}
+
+			sourceBackend := dbm.BackendType(cfg.DBBackend)
+			appBackend := server.GetAppDBBackend(svrCtx.Viper)
+			if appBackend != sourceBackend {
+				return fmt.Errorf("app-db-backend (%s) and db_backend (%s) must match to migrate", appBackend, sourceBackend)
+			}
+
+			if err := migrateDataDir(cfg.DBDir(), outputDir, sourceBackend, dbm.BackendType(targetBackend)); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Migrated %s to %s using %s.\n", cfg.DBDir(), outputDir, targetBackend)
+			fmt.Fprintf(cmd.OutOrStdout(), "Replace the data directory with %s and set db_backend = %q in config.toml and app-db-backend = %q in app.toml.\n", outputDir, targetBackend, targetBackend)
+			return nil
+		},
+	}
+
+	cmd.Flags().String(flagTargetBackend, string(dbm.PebbleDBBackend), "Database backend to migrate to")
+	cmd.Flags().String(flagOutputDir, "", "Directory to write the migrated data directory to. Defaults to <home>/data-<target-backend>")
+	return cmd
+}
+
+// migrateDataDir copies the data directory srcDir into dstDir, converting every
+// database from the source backend to the target backend.
+func migrateDataDir(srcDir, dstDir string, sourceBackend, targetBackend dbm.BackendType) error {
+	if sourceBackend == targetBackend {
+		return fmt.Errorf("source and target backend are both %s", sourceBackend)
+	}
+
+	if _, err := os.Stat(dstDir); err == nil {
+		return fmt.Errorf("output directory %s already exists", dstDir)
+	}
+
+	return filepath.WalkDir(srcDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(dstDir, rel)
+
+		if !d.IsDir() {
+			return copyFile(path, dst)
+		}
+
+		if !strings.HasSuffix(d.Name(), ".db") {
+			return os.MkdirAll(dst, 0o755)
+		}
+
+		name := strings.TrimSuffix(d.Name(), ".db")
+		if err := migrateDB(name, filepath.Dir(path), filepath.Dir(dst), sourceBackend, targetBackend); err != nil {
+			return fmt.Errorf("failed to migrate %s: %w", path, err)
+		}
+		return filepath.SkipDir
+	})
+}
+
+// migrateDB copies every key-value pair of the named database from srcDir into
+// a new database in dstDir.
+func migrateDB(name, srcDir, dstDir string, sourceBackend, targetBackend dbm.BackendType) (err error) {
+	src, err := dbm.NewDB(name, sourceBackend, srcDir)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := dbm.NewDB(name, targetBackend, dstDir)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := dst.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	itr, err := src.Iterator(nil, nil)
+	if err != nil {
+		return err
+	}
+	defer itr.Close()
+
+	batch := dst.NewBatch()
+	defer func() {
+		_ = batch.Close()
+	}()
+
+	count := 0
+	for ; itr.Valid(); itr.Next() {
+		if err := batch.Set(itr.Key(), itr.Value()); err != nil {
+			return err
+		}
+
+		count++
+		if count%migrateBatchSize == 0 {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			if err := batch.Close(); err != nil {
+				return err
+			}
+			batch = dst.NewBatch()
+		}
+	}
+	if err := itr.Error(); err != nil {
+		return err
+	}
+
+	return batch.WriteSync()
+}
+
+func copyFile(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	dbm "github.com/cosmos/cosmos-db"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_migrateDataDir(t *testing.T) {
+	srcDir := filepath.Join(t.TempDir(), "data")
+	dstDir := filepath.Join(t.TempDir(), "data-pebbledb")
+
+	for _, name := range []string{"application", "blockstore", filepath.Join("snapshots", "metadata")} {
+		db, err := dbm.NewDB(filepath.Base(name), dbm.GoLevelDBBackend, filepath.Join(srcDir, filepath.Dir(name)))
+		require.NoError(t, err)
+		for i := 0; i < migrateBatchSize+10; i++ {
+			require.NoError(t, db.Set([]byte(name+string(rune(i))), []byte{byte(i)}))
+		}
+		require.NoError(t, db.Close())
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "priv_validator_state.json"), []byte("{}"), 0o600))
+
+	require.NoError(t, migrateDataDir(srcDir, dstDir, dbm.GoLevelDBBackend, dbm.PebbleDBBackend))
+
+	for _, name := range []string{"application", "blockstore", filepath.Join("snapshots", "metadata")} {
+		db, err := dbm.NewDB(filepath.Base(name), dbm.PebbleDBBackend, filepath.Join(dstDir, filepath.Dir(name)))
+		require.NoError(t, err)
+		for i := 0; i < migrateBatchSize+10; i++ {
+			value, err := db.Get([]byte(name + string(rune(i))))
+			require.NoError(t, err)
+			require.Equal(t, []byte{byte(i)}, value)
+		}
+		require.NoError(t, db.Close())
+	}
+
+	state, err := os.ReadFile(filepath.Join(dstDir, "priv_validator_state.json"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("{}"), state)
+
+	// migrating into an existing directory fails
+	require.Error(t, migrateDataDir(srcDir, dstDir, dbm.GoLevelDBBackend, dbm.PebbleDBBackend))
+	// migrating to the same backend fails
+	require.Error(t, migrateDataDir(srcDir, t.TempDir(), dbm.GoLevelDBBackend, dbm.GoLevelDBBackend))
+}
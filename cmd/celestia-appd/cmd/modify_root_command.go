@@ -7,9 +7,10 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/celestiaorg/celestia-app/v4/app"
+	"github.com/celestiaorg/celestia-app/v4/internal/health"
 )
 
 // modifyRootCommand sets the default root command without adding a multiplexer.
-func modifyRootCommand(rootCommand *cobra.Command) {
+func modifyRootCommand(rootCommand *cobra.Command, _ *health.Server) {
 	server.AddCommands(rootCommand, app.NodeHome, NewAppServer, appExporter, addStartFlags)
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/celestiaorg/celestia-app/v4/app"
+	"github.com/celestiaorg/celestia-app/v4/internal/health"
 	"github.com/celestiaorg/celestia-app/v4/multiplexer/abci"
 	"github.com/celestiaorg/celestia-app/v4/multiplexer/appd"
 	multiplexer "github.com/celestiaorg/celestia-app/v4/multiplexer/cmd"
@@ -22,7 +23,8 @@ import (
 var v2UpgradeHeight = ""
 
 // modifyRootCommand enhances the root command with the pass through and multiplexer.
-func modifyRootCommand(rootCommand *cobra.Command) {
+// The multiplexer reports itself as not ready on the health server while switching app versions.
+func modifyRootCommand(rootCommand *cobra.Command, healthServer *health.Server) {
 	version, compressedBinary, err := embedding.CelestiaAppV3()
 	if err != nil {
 		panic(err)
@@ -66,7 +68,7 @@ func modifyRootCommand(rootCommand *cobra.Command) {
 		appExporter,
 		server.StartCmdOptions{
 			AddFlags:            addStartFlags,
-			StartCommandHandler: multiplexer.New(versions),
+			StartCommandHandler: multiplexer.New(versions, multiplexer.WithReadinessCheck(healthServer.AddCheck)),
 		},
 	)
 }
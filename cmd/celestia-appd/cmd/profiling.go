@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cosmos/cosmos-sdk/server"
+	servertypes "github.com/cosmos/cosmos-sdk/server/types"
+	"github.com/spf13/cast"
+	"github.com/spf13/cobra"
+
+	"github.com/celestiaorg/celestia-app/v4/internal/profiling"
+)
+
+const (
+	// FlagProfilingAddress is the address the profiling server listens on. An
+	// empty value disables the profiling server.
+	FlagProfilingAddress = "profiling.laddr"
+
+	// ProfilingAuthTokenKey is the app.toml key of the bearer token required by
+	// the profiling server. It is mandatory when binding to a non-loopback
+	// address. It is deliberately not a flag so that the token doesn't show up
+	// in process listings. It can also be set via the
+	// CELESTIA_APPD_PROFILING_AUTH_TOKEN environment variable.
+	ProfilingAuthTokenKey = "profiling.auth-token"
+
+	// FlagProfilingAuthTokenFile is the path to a file containing the bearer
+	// token required by the profiling server.
+	FlagProfilingAuthTokenFile = "profiling.auth-token-file"
+
+	// FlagProfilingEnablePprof serves the pprof endpoints as soon as the node
+	// starts instead of waiting for them to be toggled on.
+	FlagProfilingEnablePprof = "profiling.enable-pprof"
+)
+
+// addProfilingFlags adds the profiling server flags to the start command.
+func addProfilingFlags(startCmd *cobra.Command) {
+	startCmd.Flags().String(FlagProfilingAddress, "", "Address of the runtime-toggleable pprof and execution trace server (e.g. 127.0.0.1:6061). Disabled if empty")
+	startCmd.Flags().String(FlagProfilingAuthTokenFile, "", "Path to a file containing the bearer token required by the profiling server. Alternatively set profiling.auth-token in app.toml or CELESTIA_APPD_PROFILING_AUTH_TOKEN. Required if the profiling server is not bound to a loopback address")
+	startCmd.Flags().Bool(FlagProfilingEnablePprof, false, "Serve pprof endpoints on startup. They can be toggled at runtime via POST /debug/profiling?enabled=<bool>")
+}
+
+// startProfilingServer starts the profiling server in the background if it is
+// configured. The returned function stops the server.
+func startProfilingServer(cmd *cobra.Command) (stop func(), err error) {
+	svrCtx := server.GetServerContextFromCmd(cmd)
+	address := svrCtx.Viper.GetString(FlagProfilingAddress)
+	if address == "" {
+		return func() {}, nil
+	}
+
+	authToken, err := profilingAuthToken(svrCtx.Viper)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := profiling.Config{
+		ListenAddress: address,
+		AuthToken:     authToken,
+		EnablePprof:   svrCtx.Viper.GetBool(FlagProfilingEnablePprof),
+		TraceDir:      filepath.Join(svrCtx.Config.RootDir, "data", "traces"),
+	}
+	srv, err := profiling.NewServer(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", cfg.ListenAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on profiling address %s: %w", cfg.ListenAddress, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		if err := srv.Serve(ctx, listener); err != nil {
+			svrCtx.Logger.Error("profiling server stopped", "err", err)
+		}
+	}()
+	svrCtx.Logger.Info("started profiling server", "address", cfg.ListenAddress, "pprof_enabled", cfg.EnablePprof)
+
+	return cancel, nil
+}
+
+// profilingAuthToken returns the profiling auth token from the token file if
+// one is configured, or from app.toml or the environment otherwise.
+func profilingAuthToken(appOpts servertypes.AppOptions) (string, error) {
+	tokenFile := cast.ToString(appOpts.Get(FlagProfilingAuthTokenFile))
+	if tokenFile == "" {
+		return cast.ToString(appOpts.Get(ProfilingAuthTokenKey)), nil
+	}
+	if cast.ToString(appOpts.Get(ProfilingAuthTokenKey)) != "" {
+		return "", fmt.Errorf("only one of %s and %s may be set", FlagProfilingAuthTokenFile, ProfilingAuthTokenKey)
+	}
+
+	bz, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read profiling auth token file: %w", err)
+	}
+	token := strings.TrimSpace(string(bz))
+	if token == "" {
+		return "", fmt.Errorf("profiling auth token file %s is empty", tokenFile)
+	}
+	return token, nil
+}
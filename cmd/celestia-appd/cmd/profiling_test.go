@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	simtestutil "github.com/cosmos/cosmos-sdk/testutil/sims"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfilingAuthToken(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("from-file\n"), 0o600))
+	emptyFile := filepath.Join(t.TempDir(), "empty")
+	require.NoError(t, os.WriteFile(emptyFile, nil, 0o600))
+
+	tests := []struct {
+		name    string
+		opts    simtestutil.AppOptionsMap
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "no token",
+			opts: simtestutil.AppOptionsMap{},
+		},
+		{
+			name: "token from app.toml",
+			opts: simtestutil.AppOptionsMap{ProfilingAuthTokenKey: "from-config"},
+			want: "from-config",
+		},
+		{
+			name: "token from file",
+			opts: simtestutil.AppOptionsMap{FlagProfilingAuthTokenFile: tokenFile},
+			want: "from-file",
+		},
+		{
+			name:    "token file and app.toml token",
+			opts:    simtestutil.AppOptionsMap{FlagProfilingAuthTokenFile: tokenFile, ProfilingAuthTokenKey: "from-config"},
+			wantErr: true,
+		},
+		{
+			name:    "empty token file",
+			opts:    simtestutil.AppOptionsMap{FlagProfilingAuthTokenFile: emptyFile},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := profilingAuthToken(tc.opts)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
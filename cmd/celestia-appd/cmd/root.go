@@ -24,6 +24,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/celestiaorg/celestia-app/v4/app"
+	"github.com/celestiaorg/celestia-app/v4/internal/health"
 )
 
 const (
@@ -118,6 +119,7 @@ func initRootCommand(rootCommand *cobra.Command, capp *app.App) {
 		debugCmd,
 		confixcmd.ConfigCommand(),
 		commands.CompactGoLevelDBCmd,
+		migrateDBCommand(),
 		addrbookCommand(),
 		downloadGenesisCommand(),
 		addrConversionCmd(),
@@ -128,7 +130,8 @@ func initRootCommand(rootCommand *cobra.Command, capp *app.App) {
 		snapshot.Cmd(NewAppServer),
 	)
 
-	modifyRootCommand(rootCommand)
+	healthServer := health.NewServer()
+	modifyRootCommand(rootCommand, healthServer)
 
 	// find start command
 	startCmd, _, err := rootCommand.Find([]string{"start"})
@@ -137,14 +140,35 @@ func initRootCommand(rootCommand *cobra.Command, capp *app.App) {
 	}
 	startCmdRunE := startCmd.RunE
 
-	// Add the BBR check to the start command
+	// Add the BBR check, the profiling server, the health probes and the shutdown watchdog to the start command
 	startCmd.RunE = func(cmd *cobra.Command, args []string) error {
 		if err := checkBBR(cmd); err != nil {
 			return err
 		}
 
+		stopProfiling, err := startProfilingServer(cmd)
+		if err != nil {
+			return err
+		}
+		defer stopProfiling()
+
+		stopHealth, err := startHealthServer(cmd, healthServer)
+		if err != nil {
+			return err
+		}
+		defer stopHealth()
+
+		stopWatchdog := startShutdownWatchdog(cmd)
+		defer stopWatchdog()
+
 		return startCmdRunE(cmd, args)
 	}
+
+	exportCmd, _, err := rootCommand.Find([]string{"export"})
+	if err != nil {
+		panic(fmt.Errorf("failed to find export command: %w", err))
+	}
+	addExportFromSnapshot(exportCmd)
 }
 
 // addStartFlags adds flags to the start command.
@@ -156,6 +180,12 @@ func addStartFlags(startCmd *cobra.Command) {
 
 	startCmd.Flags().Duration(TimeoutCommitFlag, 0, "Override the application configured timeout_commit. Note: only for testing purposes.")
 	startCmd.Flags().Bool(FlagForceNoBBR, false, "bypass the requirement to use bbr locally")
+	addProfilingFlags(startCmd)
+	addHealthFlags(startCmd)
+	startCmd.Flags().String(app.FlagSnapshotWindow, "", "Only create state sync snapshots within this daily UTC window (e.g. 01:00-05:00). Snapshot heights outside the window are retained until it opens")
+	startCmd.Flags().Duration(app.FlagSnapshotMinSpacing, 0, "Minimum time between the start of two state sync snapshots. Snapshots are created one at a time and their IO is not throttled")
+	startCmd.Flags().Int(app.FlagSnapshotMaxPending, 1, "Maximum number of snapshot heights waiting for the snapshot window or spacing. The oldest pending height is dropped when exceeded")
+	startCmd.Flags().Duration(FlagShutdownTimeout, defaultShutdownTimeout, "Maximum time to drain in-flight requests, stop the embedded app and close stores on shutdown before forcing exit. Disabled if zero")
 }
 
 // replaceLogger optionally replaces the logger with a file logger if the flag
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/server"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// FlagShutdownTimeout is the maximum time the node is given to drain
+	// in-flight requests, stop the embedded app and close its stores after
+	// receiving a termination signal. The embedded app is killed a few seconds
+	// before the timeout elapses so that it isn't orphaned when the node
+	// force-exits.
+	FlagShutdownTimeout = "shutdown-timeout"
+
+	// defaultShutdownTimeout is the default value of FlagShutdownTimeout.
+	defaultShutdownTimeout = 60 * time.Second
+)
+
+// startShutdownWatchdog force-exits the process if a graceful shutdown takes
+// longer than the configured shutdown timeout. The returned function stops the
+// watchdog.
+func startShutdownWatchdog(cmd *cobra.Command) (stop func()) {
+	svrCtx := server.GetServerContextFromCmd(cmd)
+	timeout := svrCtx.Viper.GetDuration(FlagShutdownTimeout)
+	if timeout <= 0 {
+		return func() {}
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case <-done:
+			return
+		case sig := <-sigCh:
+			svrCtx.Logger.Info("shutting down gracefully", "signal", sig, "timeout", timeout)
+		}
+
+		select {
+		case <-done:
+		case <-time.After(timeout):
+			svrCtx.Logger.Error("graceful shutdown timed out, forcing exit", "timeout", timeout)
+			os.Exit(1)
+		}
+	}()
+
+	return func() {
+		signal.Stop(sigCh)
+		close(done)
+	}
+}
@@ -0,0 +1,86 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/celestiaorg/celestia-app/v4/internal/httpserver"
+)
+
+// checkTimeout bounds the time a single readiness check may take.
+const checkTimeout = 5 * time.Second
+
+// Server serves Kubernetes-style liveness (/healthz) and readiness (/readyz)
+// probes. The node is live as long as the server responds and ready once every
+// registered readiness check passes.
+type Server struct {
+	mu     sync.RWMutex
+	checks map[string]func(context.Context) error
+}
+
+// NewServer returns a health server without any readiness checks.
+func NewServer() *Server {
+	return &Server{checks: make(map[string]func(context.Context) error)}
+}
+
+// AddCheck registers a readiness check under the given name. A check returning
+// an error marks the node as not ready. Registering a check with an existing
+// name replaces it.
+func (s *Server) AddCheck(name string, check func(context.Context) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checks[name] = check
+}
+
+// Ready runs all readiness checks and returns the joined errors of the failing ones.
+func (s *Server) Ready(ctx context.Context) error {
+	s.mu.RLock()
+	names := make([]string, 0, len(s.checks))
+	for name := range s.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	checks := make([]func(context.Context) error, len(names))
+	for i, name := range names {
+		checks[i] = s.checks[name]
+	}
+	s.mu.RUnlock()
+
+	var errs error
+	for i, check := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		if err := check(checkCtx); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("%s: %w", names[i], err))
+		}
+		cancel()
+	}
+	return errs
+}
+
+// Handler returns the HTTP handler serving the probes.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if err := s.Ready(r.Context()); err != nil {
+			http.Error(w, strings.ReplaceAll(err.Error(), "\n", "; "), http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+	return mux
+}
+
+// Serve serves the probes on listener until ctx is cancelled.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	return httpserver.Serve(ctx, listener, s.Handler())
+}
@@ -0,0 +1,40 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer(t *testing.T) {
+	s := NewServer()
+	handler := s.Handler()
+
+	require.Equal(t, http.StatusOK, get(handler, "/healthz"))
+	require.Equal(t, http.StatusOK, get(handler, "/readyz"))
+
+	catchingUp := true
+	s.AddCheck("comet", func(context.Context) error {
+		if catchingUp {
+			return errors.New("node is catching up")
+		}
+		return nil
+	})
+
+	require.Equal(t, http.StatusOK, get(handler, "/healthz"))
+	require.Equal(t, http.StatusServiceUnavailable, get(handler, "/readyz"))
+	require.ErrorContains(t, s.Ready(context.Background()), "comet: node is catching up")
+
+	catchingUp = false
+	require.Equal(t, http.StatusOK, get(handler, "/readyz"))
+}
+
+func get(handler http.Handler, target string) int {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec.Code
+}
@@ -0,0 +1,45 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	// readHeaderTimeout bounds the time allowed to read request headers.
+	readHeaderTimeout = 10 * time.Second
+
+	// shutdownTimeout bounds the time in-flight requests are given to finish
+	// once the server is stopped.
+	shutdownTimeout = 5 * time.Second
+)
+
+// Serve serves handler on listener until ctx is cancelled and then shuts the
+// server down gracefully. The listener is bound by the caller so that bind
+// errors, such as an address already in use, surface before serving starts.
+func Serve(ctx context.Context, listener net.Listener, handler http.Handler) error {
+	srv := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Serve(listener)
+	}()
+
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	}
+}
@@ -0,0 +1,243 @@
+package profiling
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"path/filepath"
+	"runtime/trace"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/celestiaorg/celestia-app/v4/internal/httpserver"
+)
+
+// Config configures the profiling server.
+type Config struct {
+	// ListenAddress is the address the profiling server binds to. An empty
+	// address disables the server.
+	ListenAddress string
+	// AuthToken is an optional bearer token required on every request. It must
+	// be set when ListenAddress is not a loopback address.
+	AuthToken string
+	// EnablePprof controls whether the pprof endpoints are served on startup.
+	// It can be toggled at runtime via the /debug/profiling endpoint.
+	EnablePprof bool
+	// TraceDir is the directory execution traces are written to.
+	TraceDir string
+}
+
+// Validate returns an error if the config would expose the profiling server
+// on a non-loopback address without authentication.
+func (c Config) Validate() error {
+	if c.ListenAddress == "" {
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(c.ListenAddress)
+	if err != nil {
+		return fmt.Errorf("invalid profiling listen address %q: %w", c.ListenAddress, err)
+	}
+
+	if c.AuthToken == "" && !isLoopback(host) {
+		return fmt.Errorf("profiling listen address %q is not a loopback address and no auth token is set", c.ListenAddress)
+	}
+
+	if c.TraceDir == "" {
+		return errors.New("profiling trace directory must not be empty")
+	}
+
+	return nil
+}
+
+// Server serves pprof endpoints and controls execution tracing. Both can be
+// switched on and off at runtime without restarting the node.
+type Server struct {
+	cfg          Config
+	pprofEnabled atomic.Bool
+
+	mu        sync.Mutex
+	traceFile *os.File
+}
+
+// NewServer returns a new profiling server for the given config.
+func NewServer(cfg Config) (*Server, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	s := &Server{cfg: cfg}
+	s.pprofEnabled.Store(cfg.EnablePprof)
+	return s, nil
+}
+
+// Handler returns the HTTP handler of the profiling server.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", s.requirePprof(pprof.Index))
+	mux.HandleFunc("/debug/pprof/cmdline", s.requirePprof(pprof.Cmdline))
+	mux.HandleFunc("/debug/pprof/profile", s.requirePprof(pprof.Profile))
+	mux.HandleFunc("/debug/pprof/symbol", s.requirePprof(pprof.Symbol))
+	mux.HandleFunc("/debug/pprof/trace", s.requirePprof(pprof.Trace))
+	mux.HandleFunc("/debug/profiling", s.handleToggle)
+	mux.HandleFunc("/debug/trace/start", s.handleTraceStart)
+	mux.HandleFunc("/debug/trace/stop", s.handleTraceStop)
+	return s.authenticate(mux)
+}
+
+// Serve serves the profiling server on listener until ctx is cancelled. Any
+// in-progress execution trace is stopped on shutdown.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	defer func() {
+		_, _ = s.stopTrace()
+	}()
+	return httpserver.Serve(ctx, listener, s.Handler())
+}
+
+// PprofEnabled returns true if the pprof endpoints are currently served.
+func (s *Server) PprofEnabled() bool {
+	return s.pprofEnabled.Load()
+}
+
+// TraceActive returns true if an execution trace is currently being recorded.
+func (s *Server) TraceActive() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.traceFile != nil
+}
+
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	if s.cfg.AuthToken == "" {
+		return next
+	}
+
+	expected := []byte("Bearer " + s.cfg.AuthToken)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) requirePprof(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.pprofEnabled.Load() {
+			http.Error(w, "pprof endpoints are disabled", http.StatusServiceUnavailable)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// handleToggle reports the pprof state on GET and updates it on POST using the
+// "enabled" query parameter.
+func (s *Server) handleToggle(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		enabled, err := strconv.ParseBool(r.URL.Query().Get("enabled"))
+		if err != nil {
+			http.Error(w, "invalid enabled parameter", http.StatusBadRequest)
+			return
+		}
+		s.pprofEnabled.Store(enabled)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	fmt.Fprintf(w, "pprof_enabled=%t trace_active=%t\n", s.PprofEnabled(), s.TraceActive())
+}
+
+func (s *Server) handleTraceStart(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	path, err := s.startTrace()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	fmt.Fprintf(w, "tracing to %s\n", path)
+}
+
+func (s *Server) handleTraceStop(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	path, err := s.stopTrace()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	fmt.Fprintf(w, "trace written to %s\n", path)
+}
+
+// startTrace begins recording an execution trace to a new file in TraceDir.
+func (s *Server) startTrace() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.traceFile != nil {
+		return "", fmt.Errorf("trace already in progress: %s", s.traceFile.Name())
+	}
+
+	if err := os.MkdirAll(s.cfg.TraceDir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create trace directory: %w", err)
+	}
+
+	path := filepath.Join(s.cfg.TraceDir, fmt.Sprintf("trace-%d.out", time.Now().UnixNano()))
+	f, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to create trace file: %w", err)
+	}
+
+	if err := trace.Start(f); err != nil {
+		f.Close()
+		return "", fmt.Errorf("failed to start trace: %w", err)
+	}
+
+	s.traceFile = f
+	return path, nil
+}
+
+// stopTrace stops the active execution trace and returns the file it was
+// written to.
+func (s *Server) stopTrace() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.traceFile == nil {
+		return "", errors.New("no trace in progress")
+	}
+
+	trace.Stop()
+	path := s.traceFile.Name()
+	err := s.traceFile.Close()
+	s.traceFile = nil
+	if err != nil {
+		return path, fmt.Errorf("failed to close trace file: %w", err)
+	}
+	return path, nil
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
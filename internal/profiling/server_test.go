@@ -0,0 +1,103 @@
+package profiling
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{
+			name: "disabled config is valid",
+			cfg:  Config{},
+		},
+		{
+			name: "loopback address without token is valid",
+			cfg:  Config{ListenAddress: "127.0.0.1:6061", TraceDir: "trace"},
+		},
+		{
+			name: "localhost without token is valid",
+			cfg:  Config{ListenAddress: "localhost:6061", TraceDir: "trace"},
+		},
+		{
+			name:    "public address without token is invalid",
+			cfg:     Config{ListenAddress: "0.0.0.0:6061", TraceDir: "trace"},
+			wantErr: true,
+		},
+		{
+			name: "public address with token is valid",
+			cfg:  Config{ListenAddress: "0.0.0.0:6061", AuthToken: "secret", TraceDir: "trace"},
+		},
+		{
+			name:    "address without port is invalid",
+			cfg:     Config{ListenAddress: "127.0.0.1", TraceDir: "trace"},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Validate()
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestServerToggle(t *testing.T) {
+	s, err := NewServer(Config{ListenAddress: "127.0.0.1:0", TraceDir: t.TempDir()})
+	require.NoError(t, err)
+	handler := s.Handler()
+
+	require.Equal(t, http.StatusServiceUnavailable, serve(handler, http.MethodGet, "/debug/pprof/", ""))
+
+	require.Equal(t, http.StatusOK, serve(handler, http.MethodPost, "/debug/profiling?enabled=true", ""))
+	require.True(t, s.PprofEnabled())
+	require.Equal(t, http.StatusOK, serve(handler, http.MethodGet, "/debug/pprof/", ""))
+
+	require.Equal(t, http.StatusOK, serve(handler, http.MethodPost, "/debug/profiling?enabled=false", ""))
+	require.False(t, s.PprofEnabled())
+	require.Equal(t, http.StatusBadRequest, serve(handler, http.MethodPost, "/debug/profiling?enabled=maybe", ""))
+}
+
+func TestServerTrace(t *testing.T) {
+	s, err := NewServer(Config{ListenAddress: "127.0.0.1:0", TraceDir: t.TempDir()})
+	require.NoError(t, err)
+	handler := s.Handler()
+
+	require.Equal(t, http.StatusConflict, serve(handler, http.MethodPost, "/debug/trace/stop", ""))
+	require.Equal(t, http.StatusOK, serve(handler, http.MethodPost, "/debug/trace/start", ""))
+	require.True(t, s.TraceActive())
+	require.Equal(t, http.StatusConflict, serve(handler, http.MethodPost, "/debug/trace/start", ""))
+	require.Equal(t, http.StatusOK, serve(handler, http.MethodPost, "/debug/trace/stop", ""))
+	require.False(t, s.TraceActive())
+}
+
+func TestServerAuth(t *testing.T) {
+	s, err := NewServer(Config{ListenAddress: "0.0.0.0:0", AuthToken: "secret", TraceDir: t.TempDir()})
+	require.NoError(t, err)
+	handler := s.Handler()
+
+	require.Equal(t, http.StatusUnauthorized, serve(handler, http.MethodGet, "/debug/profiling", ""))
+	require.Equal(t, http.StatusUnauthorized, serve(handler, http.MethodGet, "/debug/profiling", "Bearer wrong"))
+	require.Equal(t, http.StatusOK, serve(handler, http.MethodGet, "/debug/profiling", "Bearer secret"))
+}
+
+func serve(handler http.Handler, method, target, auth string) int {
+	req := httptest.NewRequest(method, target, nil)
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec.Code
+}
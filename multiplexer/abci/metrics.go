@@ -0,0 +1,99 @@
+package abci
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/telemetry"
+	"google.golang.org/grpc"
+)
+
+const (
+	// metricsUpdateInterval is how often the embedded app gauges are refreshed.
+	metricsUpdateInterval = 10 * time.Second
+
+	// nativeAppLabel is the version label used for the native app in handover metrics.
+	nativeAppLabel = "native"
+)
+
+// proxyLatencyInterceptor records the latency of every request proxied to the
+// embedded app.
+func proxyLatencyInterceptor(
+	ctx context.Context,
+	method string,
+	req, reply any,
+	cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker,
+	opts ...grpc.CallOption,
+) error {
+	defer telemetry.MeasureSinceWithLabels(
+		[]string{"multiplexer", "proxy", "latency"},
+		time.Now(),
+		[]telemetry.Label{telemetry.NewLabel("method", method)},
+	)
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// emitHandoverMetrics records a handover from one app version to another.
+func emitHandoverMetrics(from, to string) {
+	telemetry.IncrCounterWithLabels(
+		[]string{"multiplexer", "handover"},
+		1,
+		[]telemetry.Label{
+			telemetry.NewLabel("from", from),
+			telemetry.NewLabel("to", to),
+		},
+	)
+}
+
+// emitEmbeddedAppMetrics records the uptime, unexpected exits and last exit
+// code of every embedded app.
+func (m *Multiplexer) emitEmbeddedAppMetrics() {
+	if m.reportedUnexpectedExits == nil {
+		m.reportedUnexpectedExits = make(map[uint64]uint64)
+	}
+
+	for _, v := range m.versions {
+		if v.Appd == nil {
+			continue
+		}
+
+		labels := []telemetry.Label{
+			telemetry.NewLabel("version", v.Appd.Version()),
+			telemetry.NewLabel("app_version", strconv.FormatUint(v.AppVersion, 10)),
+		}
+		telemetry.SetGaugeWithLabels([]string{"multiplexer", "appd", "uptime_seconds"}, float32(v.Appd.Uptime().Seconds()), labels)
+		// only the exits since the previous update are added to the counter.
+		unexpectedExits := v.Appd.UnexpectedExits()
+		if n := unexpectedExits - m.reportedUnexpectedExits[v.AppVersion]; n > 0 {
+			telemetry.IncrCounterWithLabels([]string{"multiplexer", "appd", "unexpected_exits"}, float32(n), labels)
+		}
+		m.reportedUnexpectedExits[v.AppVersion] = unexpectedExits
+		telemetry.SetGaugeWithLabels([]string{"multiplexer", "appd", "last_exit_code"}, float32(v.Appd.LastExitCode()), labels)
+	}
+}
+
+// monitorEmbeddedApps periodically emits the embedded app metrics until ctx is done.
+func (m *Multiplexer) monitorEmbeddedApps(ctx context.Context) error {
+	ticker := time.NewTicker(metricsUpdateInterval)
+	defer ticker.Stop()
+
+	for {
+		m.emitEmbeddedAppMetrics()
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// versionLabel returns the label used in handover metrics for the given version.
+func versionLabel(v Version) string {
+	if v.Appd == nil {
+		return nativeAppLabel
+	}
+	return v.Appd.Version()
+}
@@ -12,7 +12,9 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"cosmossdk.io/log"
 	cmtcfg "github.com/cometbft/cometbft/config"
@@ -43,8 +45,14 @@ import (
 )
 
 const (
-	flagTraceStore = "trace-store"
-	flagGRPCOnly   = "grpc-only"
+	flagTraceStore      = "trace-store"
+	flagGRPCOnly        = "grpc-only"
+	flagShutdownTimeout = "shutdown-timeout"
+
+	// shutdownMargin is the part of the shutdown timeout reserved for killing
+	// the embedded app and closing the remaining resources before the shutdown
+	// watchdog force-exits the process.
+	shutdownMargin = 5 * time.Second
 )
 
 // Multiplexer is responsible for managing multiple versions of applications and coordinating their lifecycle.
@@ -64,6 +72,8 @@ type Multiplexer struct {
 	nextAppVersion uint64
 	// started indicates if there is an embedded app or native app running
 	started bool
+	// handingOver indicates that the multiplexer is switching between app versions.
+	handingOver atomic.Bool
 	// appCreator is a function type responsible for creating a new application instance.
 	appCreator servertypes.AppCreator
 	// nativeApp represents the instance of a native application.
@@ -76,6 +86,8 @@ type Multiplexer struct {
 	// cmNode is the comet node which has been created. A reference is required in order to establish
 	// a local connection to it.
 	cmNode *node.Node
+	// stopCmtNode stops the comet node. It is nil until the comet node has been started.
+	stopCmtNode func() error
 	// versions is a list of versions which contain all embedded binaries.
 	versions Versions
 	// conn is a grpc client connection and used when creating remote ABCI connections.
@@ -87,6 +99,12 @@ type Multiplexer struct {
 	ctx context.Context
 	// g is the waitgroup to which the comet, grpc and api server init functions are added to.
 	g *errgroup.Group
+	// metrics is the application telemetry. It is initialized once on Start so that the
+	// multiplexer metrics are available for both embedded and native apps.
+	metrics *telemetry.Metrics
+	// reportedUnexpectedExits is the number of unexpected exits of each embedded
+	// app version that has been added to the unexpected exits counter.
+	reportedUnexpectedExits map[uint64]uint64
 }
 
 // NewMultiplexer creates a new Multiplexer.
@@ -126,6 +144,38 @@ func (m *Multiplexer) isGrpcOnly() bool {
 	return m.svrCtx.Viper.GetBool(flagGRPCOnly)
 }
 
+// Ready returns an error if the multiplexer is switching between app versions
+// and is therefore not able to serve requests.
+func (m *Multiplexer) Ready(context.Context) error {
+	if m.handingOver.Load() {
+		return errors.New("multiplexer is switching app versions")
+	}
+	return nil
+}
+
+// shutdownTimeout returns the time given to the embedded app to exit gracefully.
+func (m *Multiplexer) shutdownTimeout() time.Duration {
+	if timeout := m.svrCtx.Viper.GetDuration(flagShutdownTimeout); timeout > 0 {
+		return timeout
+	}
+	return appd.DefaultStopTimeout
+}
+
+// cleanupStopTimeout returns the time given to the embedded app to exit
+// gracefully during a shutdown that started at shutdownStart. It ends before
+// the shutdown watchdog force-exits the process so that the embedded app is
+// killed rather than orphaned while holding the database locks.
+func (m *Multiplexer) cleanupStopTimeout(shutdownStart time.Time) time.Duration {
+	timeout := m.svrCtx.Viper.GetDuration(flagShutdownTimeout)
+	if timeout <= 0 {
+		// the shutdown watchdog is disabled.
+		return appd.DefaultStopTimeout
+	}
+
+	margin := min(shutdownMargin, timeout/2)
+	return max(time.Until(shutdownStart.Add(timeout-margin)), 0)
+}
+
 // registerCleanupFn enables the registration of additional cleanup functions that get called during Cleanup
 func (m *Multiplexer) registerCleanupFn(cleanUpFn func() error) {
 	m.cleanupFns = append(m.cleanupFns, cleanUpFn)
@@ -134,7 +184,16 @@ func (m *Multiplexer) registerCleanupFn(cleanUpFn func() error) {
 func (m *Multiplexer) Start() error {
 	m.g, m.ctx = getCtx(m.svrCtx, true)
 
+	metrics, err := startTelemetry(m.svrCfg)
+	if err != nil {
+		return err
+	}
+	m.metrics = metrics
+
 	emitServerInfoMetrics()
+	m.g.Go(func() error {
+		return m.monitorEmbeddedApps(m.ctx)
+	})
 
 	// startApp starts the underlying application, either native or embedded.
 	if err := m.startApp(); err != nil {
@@ -193,12 +252,7 @@ func (m *Multiplexer) enableGRPCAndAPIServers(app servertypes.Application) error
 		// startAPIServer starts the api server for a native app. If using an embedded app
 		// it will use that instead.
 		if m.svrCfg.API.Enable {
-			metrics, err := startTelemetry(m.svrCfg)
-			if err != nil {
-				return err
-			}
-
-			if err := m.startAPIServer(grpcServer, metrics); err != nil {
+			if err := m.startAPIServer(grpcServer, m.metrics); err != nil {
 				return err
 			}
 		}
@@ -289,6 +343,7 @@ func (m *Multiplexer) initRemoteGrpcConn() error {
 			grpc.MaxCallSendMsgSize(math.MaxInt32),
 			grpc.MaxCallRecvMsgSize(math.MaxInt32),
 		),
+		grpc.WithUnaryInterceptor(proxyLatencyInterceptor),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to prepare app connection: %w", err)
@@ -459,13 +514,19 @@ func (m *Multiplexer) getApp() (servertypes.ABCI, error) {
 	// get the appropriate version for the latest app version.
 	currentVersion, err := m.versions.GetForAppVersion(m.appVersion)
 	if err != nil {
-		// if we are switching from an embedded binary to a native one, we need to ensure that we stop it
-		// before we start the native app.
-		if err := m.stopEmbeddedApp(); err != nil {
-			return nil, fmt.Errorf("failed to stop embedded app: %w", err)
-		}
-
 		if m.nativeApp == nil {
+			// the handover covers stopping the embedded app as well as starting the native app.
+			m.handingOver.Store(true)
+			defer m.handingOver.Store(false)
+
+			previousVersion := m.activeVersion
+
+			// if we are switching from an embedded binary to a native one, we need to ensure that we stop it
+			// before we start the native app.
+			if err := m.stopEmbeddedApp(m.shutdownTimeout()); err != nil {
+				return nil, fmt.Errorf("failed to stop embedded app: %w", err)
+			}
+
 			m.logger.Info("using latest app", "app_version", m.appVersion)
 			app, err := m.startNativeApp()
 			if err != nil {
@@ -477,12 +538,19 @@ func (m *Multiplexer) getApp() (servertypes.ABCI, error) {
 			if err := m.enableGRPCAndAPIServers(app); err != nil {
 				return nil, fmt.Errorf("failed to enable gRPC and API servers: %w", err)
 			}
+
+			if previousVersion.Appd != nil {
+				emitHandoverMetrics(versionLabel(previousVersion), nativeAppLabel)
+			}
 		}
 		return m.nativeApp, nil
 	}
 
 	// check if we need to start the app or if we have a different app running
 	if !m.started || currentVersion.AppVersion > m.activeVersion.AppVersion {
+		m.handingOver.Store(true)
+		defer m.handingOver.Store(false)
+
 		m.logger.Info("Using ABCI remote connection", "maximum_app_version", m.activeVersion.AppVersion, "abci_version", m.activeVersion.ABCIVersion.String(), "chain_id", m.chainID)
 		if err := m.startEmbeddedApp(currentVersion); err != nil {
 			return nil, fmt.Errorf("failed to start embedded app: %w", err)
@@ -506,8 +574,10 @@ func (m *Multiplexer) startEmbeddedApp(version Version) error {
 		return fmt.Errorf("appd is nil for version %d", m.activeVersion.AppVersion)
 	}
 
+	previousVersion := m.activeVersion
+
 	// stop the existing app version if one is currently running.
-	if err := m.stopEmbeddedApp(); err != nil {
+	if err := m.stopEmbeddedApp(m.shutdownTimeout()); err != nil {
 		return fmt.Errorf("failed to stop active version: %w", err)
 	}
 
@@ -534,6 +604,10 @@ func (m *Multiplexer) startEmbeddedApp(version Version) error {
 
 		m.activeVersion = version
 		m.started = true
+
+		if previousVersion.Appd != nil {
+			emitHandoverMetrics(versionLabel(previousVersion), versionLabel(version))
+		}
 	}
 	return nil
 }
@@ -544,10 +618,11 @@ func (m *Multiplexer) embeddedVersionRunning() bool {
 }
 
 // stopEmbeddedApp stops any embedded app versions if they are currently running.
-func (m *Multiplexer) stopEmbeddedApp() error {
+// The embedded app is killed if it does not exit within the given timeout.
+func (m *Multiplexer) stopEmbeddedApp(timeout time.Duration) error {
 	if m.embeddedVersionRunning() {
 		m.logger.Info("stopping app for version", "active_app_version", m.activeVersion.AppVersion)
-		if err := m.activeVersion.Appd.Stop(); err != nil {
+		if err := m.activeVersion.Appd.StopWithTimeout(timeout); err != nil {
 			return fmt.Errorf("failed to stop app for version %d: %w", m.activeVersion.AppVersion, err)
 		}
 		m.started = false
@@ -557,7 +632,13 @@ func (m *Multiplexer) stopEmbeddedApp() error {
 }
 
 // Cleanup allows proper multiplexer termination.
+// The comet node is stopped first so that no blocks are executed while the app and its
+// stores are closed. The remaining cleanup functions run in the reverse order of their registration.
 func (m *Multiplexer) Cleanup() error {
+	// the shutdown watchdog starts counting when the termination signal is
+	// received, which is when Cleanup is called for embedded apps.
+	shutdownStart := time.Now()
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -565,8 +646,22 @@ func (m *Multiplexer) Cleanup() error {
 
 	var errs error
 
-	// stop any running app
-	if err := m.stopEmbeddedApp(); err != nil {
+	if m.stopCmtNode != nil {
+		if err := m.stopCmtNode(); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("failed to stop comet node: %w", err))
+		}
+		m.stopCmtNode = nil
+	}
+
+	for i := len(m.cleanupFns) - 1; i >= 0; i-- {
+		if err := m.cleanupFns[i](); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("failed to run cleanup function: %w", err))
+		}
+	}
+	m.cleanupFns = nil
+
+	// stop any running app within what is left of the shutdown timeout
+	if err := m.stopEmbeddedApp(m.cleanupStopTimeout(shutdownStart)); err != nil {
 		errs = errors.Join(errs, fmt.Errorf("failed to stop active version: %w", err))
 	}
 
@@ -578,12 +673,6 @@ func (m *Multiplexer) Cleanup() error {
 		m.conn = nil
 	}
 
-	for _, fn := range m.cleanupFns {
-		if err := fn(); err != nil {
-			errs = errors.Join(errs, fmt.Errorf("failed to run cleanup function: %w", err))
-		}
-	}
-
 	return errs
 }
 
@@ -620,12 +709,12 @@ func (m *Multiplexer) startCmtNode() error {
 		return err
 	}
 
-	m.registerCleanupFn(func() error {
-		if tmNode != nil && tmNode.IsRunning() {
+	m.stopCmtNode = func() error {
+		if tmNode.IsRunning() {
 			return tmNode.Stop()
 		}
 		return nil
-	})
+	}
 
 	m.cmNode = tmNode
 	return nil
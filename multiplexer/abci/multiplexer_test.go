@@ -1,9 +1,25 @@
 package abci
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	clienthelpers "cosmossdk.io/client/v2/helpers"
+	"cosmossdk.io/log"
+	dbm "github.com/cosmos/cosmos-db"
+	"github.com/cosmos/cosmos-sdk/server"
+	servertypes "github.com/cosmos/cosmos-sdk/server/types"
 	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/celestia-app/v4/multiplexer/appd"
 )
 
 func TestOpenTraceWriter(t *testing.T) {
@@ -52,3 +68,146 @@ func Test_getProgramArgs(t *testing.T) {
 		require.Equal(t, test.want, got)
 	}
 }
+
+func TestCleanupStopTimeout(t *testing.T) {
+	svrCtx := server.NewDefaultContext()
+	m := &Multiplexer{svrCtx: svrCtx}
+
+	t.Run("uses the default stop timeout if the shutdown watchdog is disabled", func(t *testing.T) {
+		svrCtx.Viper.Set(flagShutdownTimeout, time.Duration(0))
+		require.Equal(t, appd.DefaultStopTimeout, m.cleanupStopTimeout(time.Now()))
+	})
+
+	t.Run("ends before the shutdown watchdog fires", func(t *testing.T) {
+		svrCtx.Viper.Set(flagShutdownTimeout, time.Minute)
+		got := m.cleanupStopTimeout(time.Now())
+		require.Greater(t, got, time.Duration(0))
+		require.LessOrEqual(t, got, time.Minute-shutdownMargin)
+	})
+
+	t.Run("accounts for the time already spent shutting down", func(t *testing.T) {
+		svrCtx.Viper.Set(flagShutdownTimeout, time.Minute)
+		got := m.cleanupStopTimeout(time.Now().Add(-50 * time.Second))
+		require.LessOrEqual(t, got, 5*time.Second)
+	})
+
+	t.Run("kills immediately once the budget is exhausted", func(t *testing.T) {
+		svrCtx.Viper.Set(flagShutdownTimeout, time.Minute)
+		require.Equal(t, time.Duration(0), m.cleanupStopTimeout(time.Now().Add(-time.Hour)))
+	})
+}
+
+func TestCleanupStopsCometNodeFirst(t *testing.T) {
+	m := &Multiplexer{svrCtx: server.NewDefaultContext(), logger: log.NewNopLogger()}
+
+	var stopped []string
+	// on the embedded to native app handover, the comet node is started
+	// before the native app registers its cleanup functions.
+	m.stopCmtNode = func() error {
+		stopped = append(stopped, "comet node")
+		return nil
+	}
+	m.registerCleanupFn(func() error {
+		stopped = append(stopped, "trace writer")
+		return nil
+	})
+	m.registerCleanupFn(func() error {
+		stopped = append(stopped, "native app")
+		return nil
+	})
+
+	require.NoError(t, m.Cleanup())
+	require.Equal(t, []string{"comet node", "native app", "trace writer"}, stopped)
+}
+
+// TestGetAppNotReadyDuringNativeHandover ensures that the multiplexer reports
+// that it is not ready while the embedded app is stopped on the handover to
+// the native app.
+func TestGetAppNotReadyDuringNativeHandover(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test which starts a mock embedded binary")
+	}
+
+	// the embedded app ignores the interrupt signal so that it is only killed
+	// once the shutdown timeout elapses.
+	embedded := newMockAppd(t, "trap '' INT TERM; exec sleep 30")
+	require.NoError(t, embedded.Start())
+	// give the shell time to install the trap
+	time.Sleep(100 * time.Millisecond)
+	embeddedVersion := Version{AppVersion: 3, ABCIVersion: ABCIClientVersion2, Appd: embedded}
+
+	svrCtx := server.NewDefaultContext()
+	svrCtx.Config.SetRoot(t.TempDir())
+	svrCtx.Viper.Set(flagShutdownTimeout, time.Second)
+
+	m := &Multiplexer{
+		svrCtx:        svrCtx,
+		logger:        log.NewNopLogger(),
+		appVersion:    4,
+		versions:      Versions{embeddedVersion},
+		activeVersion: embeddedVersion,
+		started:       true,
+		appCreator: func(_ log.Logger, db dbm.DB, _ io.Writer, _ servertypes.AppOptions) servertypes.Application {
+			return stubApp{db: db}
+		},
+	}
+	t.Cleanup(func() {
+		require.NoError(t, m.Cleanup())
+	})
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := m.getApp()
+		errCh <- err
+	}()
+
+	require.Eventually(t, func() bool {
+		return m.Ready(context.Background()) != nil && embedded.Pid() != appd.AppdStopped
+	}, 5*time.Second, 10*time.Millisecond, "multiplexer reported ready while stopping the embedded app")
+
+	require.NoError(t, <-errCh)
+	require.Equal(t, appd.AppdStopped, embedded.Pid())
+	require.NoError(t, m.Ready(context.Background()))
+}
+
+// stubApp is a native app which only closes its database.
+type stubApp struct {
+	servertypes.Application
+	db dbm.DB
+}
+
+func (a stubApp) Close() error {
+	return a.db.Close()
+}
+
+// newMockAppd returns an embedded app whose binary runs startScript when started.
+func newMockAppd(t *testing.T, startScript string) *appd.Appd {
+	t.Helper()
+
+	script := "#!/bin/sh\nif [ \"$1\" = \"start\" ]; then\n" + startScript + "\nfi\n"
+	var compressed bytes.Buffer
+	gzipWriter := gzip.NewWriter(&compressed)
+	tarWriter := tar.NewWriter(gzipWriter)
+	require.NoError(t, tarWriter.WriteHeader(&tar.Header{
+		Name:     "celestia-appd",
+		Typeflag: tar.TypeReg,
+		Mode:     0o755,
+		Size:     int64(len(script)),
+	}))
+	_, err := tarWriter.Write([]byte(script))
+	require.NoError(t, err)
+	require.NoError(t, tarWriter.Close())
+	require.NoError(t, gzipWriter.Close())
+
+	// the binary is decompressed into the binary directory of the node home.
+	version := fmt.Sprintf("v0.0.0-multiplexer-test-%d", time.Now().UnixNano())
+	nodeHome, err := clienthelpers.GetNodeHomeDirectory(".celestia-app")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, os.RemoveAll(filepath.Join(nodeHome, "bin", version)))
+	})
+
+	embedded, err := appd.New(version, compressed.Bytes())
+	require.NoError(t, err)
+	return embedded
+}
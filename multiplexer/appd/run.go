@@ -4,17 +4,24 @@ import (
 	"archive/tar"
 	"bytes"
 	"compress/gzip"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sync"
+	"time"
 )
 
 const (
 	// AppdStopped is the process ID of the celestia-appd binary when it is not running.
 	AppdStopped = -1
+
+	// DefaultStopTimeout is the time given to the celestia-appd binary to exit
+	// gracefully before it is killed.
+	DefaultStopTimeout = 30 * time.Second
 )
 
 // Appd represents a celestia-appd binary.
@@ -22,13 +29,25 @@ type Appd struct {
 	// version is the version of the celestia-appd binary.
 	// Example: "v3.10.0-arabica"
 	version string
-	// pid is the process ID of the celestia-appd binary.
+	// pid is the process ID of the celestia-appd binary. It is guarded by mu.
 	pid int
 	// path is the path to the celestia-appd binary.
 	path   string
 	stdin  io.Reader
 	stderr io.Writer
 	stdout io.Writer
+
+	mu sync.Mutex
+	// startedAt is the time the currently running process was started.
+	startedAt time.Time
+	// stopping is true if the currently running process is being stopped by StopWithTimeout.
+	stopping bool
+	// unexpectedExits is the number of processes that exited without being stopped by StopWithTimeout.
+	unexpectedExits uint64
+	// lastExitCode is the exit code of the most recently exited process.
+	lastExitCode int
+	// exited is closed once the currently running process has exited.
+	exited chan struct{}
 }
 
 // New returns a new Appd instance.
@@ -74,54 +93,137 @@ func (a *Appd) Start(args ...string) error {
 		return fmt.Errorf("failed to start %s: %w", a.path, err)
 	}
 
+	exited := make(chan struct{})
+	a.mu.Lock()
 	a.pid = cmd.Process.Pid
+	a.startedAt = time.Now()
+	a.stopping = false
+	a.exited = exited
+	a.mu.Unlock()
+
 	go func() {
+		defer close(exited)
+
 		// wait for process to finish
 		if err := cmd.Wait(); err != nil {
 			log.Printf("Process finished with error: %v\n", err)
 		}
 
-		a.pid = -1 // reset pid
+		a.mu.Lock()
+		a.lastExitCode = cmd.ProcessState.ExitCode()
+		if !a.stopping {
+			a.unexpectedExits++
+		}
+		a.pid = AppdStopped // reset pid
+		a.mu.Unlock()
 	}()
 
 	return nil
 }
 
-// Stop terminates the running appd process if it exists.
+// Stop terminates the running appd process if it exists. The process is
+// killed if it does not exit within DefaultStopTimeout.
 func (a *Appd) Stop() error {
-	if a.pid == AppdStopped {
+	return a.StopWithTimeout(DefaultStopTimeout)
+}
+
+// StopWithTimeout terminates the running appd process if it exists. The
+// process is asked to shut down gracefully and is killed if it does not exit
+// within the given timeout.
+func (a *Appd) StopWithTimeout(timeout time.Duration) error {
+	a.mu.Lock()
+	pid := a.pid
+	exited := a.exited
+	if pid != AppdStopped {
+		a.stopping = true
+	}
+	a.mu.Unlock()
+	if pid == AppdStopped {
 		return nil
 	}
 
-	process, err := os.FindProcess(a.pid)
+	process, err := os.FindProcess(pid)
 	if err != nil {
-		return fmt.Errorf("failed to find process with PID %d: %w", a.pid, err)
+		return fmt.Errorf("failed to find process with PID %d: %w", pid, err)
 	}
 
 	// send SIGTERM for graceful shutdown
-	if err := process.Signal(os.Interrupt); err != nil {
+	if err := process.Signal(os.Interrupt); err != nil && !errors.Is(err, os.ErrProcessDone) {
 		log.Printf("Failed to send interrupt signal, attempting to kill: %v", err)
 		// if interrupt fails, try harder with Kill
-		if err := process.Kill(); err != nil {
-			return fmt.Errorf("failed to kill process with PID %d: %w", a.pid, err)
+		if err := killProcess(process); err != nil {
+			return fmt.Errorf("failed to kill process with PID %d: %w", pid, err)
 		}
 	}
 
-	// Wait for the process to exit
-	_, err = process.Wait()
-	if err != nil {
-		log.Printf("Error waiting for process to exit: %v", err)
+	// Wait for the process to exit. If the process was not started by Start,
+	// there is no exit notification to wait on.
+	if exited != nil {
+		select {
+		case <-exited:
+		case <-time.After(timeout):
+			log.Printf("Process with PID %d did not exit within %s, killing it", pid, timeout)
+			if err := killProcess(process); err != nil {
+				return fmt.Errorf("failed to kill process with PID %d: %w", pid, err)
+			}
+			<-exited
+		}
 	}
 
+	a.mu.Lock()
 	a.pid = AppdStopped
+	a.mu.Unlock()
+	return nil
+}
+
+// killProcess kills the process. A process that has already exited is not
+// treated as an error.
+func killProcess(process *os.Process) error {
+	if err := process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return err
+	}
 	return nil
 }
 
 // Pid returns the process ID of the appd process.
 func (a *Appd) Pid() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 	return a.pid
 }
 
+// Uptime returns how long the appd process has been running. It returns zero
+// if the process is not running.
+func (a *Appd) Uptime() time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.pid == AppdStopped {
+		return 0
+	}
+	return time.Since(a.startedAt)
+}
+
+// UnexpectedExits returns the number of appd processes that exited without
+// being stopped by Stop or StopWithTimeout, e.g. because they crashed.
+func (a *Appd) UnexpectedExits() uint64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.unexpectedExits
+}
+
+// LastExitCode returns the exit code of the most recently exited appd
+// process. It returns -1 if the process was terminated by a signal.
+func (a *Appd) LastExitCode() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.lastExitCode
+}
+
+// Version returns the version of the appd binary.
+func (a *Appd) Version() string {
+	return a.version
+}
+
 // CreateExecCommand creates an exec.Cmd for the appd binary.
 func (a *Appd) CreateExecCommand(args ...string) *exec.Cmd {
 	cmd := exec.Command(a.path, args...)
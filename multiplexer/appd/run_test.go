@@ -5,8 +5,10 @@ package appd
 import (
 	"bytes"
 	"os"
+	"os/exec"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/celestiaorg/celestia-app/v4/internal/embedding"
 
@@ -67,6 +69,98 @@ func TestStart_Success(t *testing.T) {
 	require.NoError(t, err, "Stop should terminate the process")
 }
 
+// TestStart_RecordsExitCodeAndUnexpectedExits ensures that the exit code of the
+// process is tracked and that only exits not caused by Stop are counted as unexpected.
+func TestStart_RecordsExitCodeAndUnexpectedExits(t *testing.T) {
+	mockBinary := createMockExecutable(t, "exit 3")
+	defer os.Remove(mockBinary) // Cleanup after test
+
+	appdInstance := &Appd{
+		path:   mockBinary,
+		stdin:  os.Stdin,
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+		pid:    AppdStopped,
+	}
+
+	require.NoError(t, appdInstance.Start())
+	require.Eventually(t, func() bool {
+		return appdInstance.Pid() == AppdStopped
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, 3, appdInstance.LastExitCode())
+	require.Equal(t, uint64(1), appdInstance.UnexpectedExits())
+	require.Equal(t, time.Duration(0), appdInstance.Uptime())
+
+	appdInstance.path = createMockExecutable(t, "exec sleep 30")
+	defer os.Remove(appdInstance.path) // Cleanup after test
+	require.NoError(t, appdInstance.Start())
+	require.NoError(t, appdInstance.Stop())
+	require.Equal(t, uint64(1), appdInstance.UnexpectedExits())
+}
+
+// TestStopWithTimeout_KillsUnresponsiveProcess ensures that a process ignoring the
+// interrupt signal is killed once the stop timeout elapses.
+func TestStopWithTimeout_KillsUnresponsiveProcess(t *testing.T) {
+	mockBinary := createMockExecutable(t, "trap '' INT TERM; sleep 30")
+	defer os.Remove(mockBinary) // Cleanup after test
+
+	appdInstance := &Appd{
+		path:   mockBinary,
+		stdin:  os.Stdin,
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+		pid:    AppdStopped,
+	}
+
+	require.NoError(t, appdInstance.Start())
+	// give the shell time to install the trap
+	time.Sleep(100 * time.Millisecond)
+
+	start := time.Now()
+	require.NoError(t, appdInstance.StopWithTimeout(200*time.Millisecond))
+	require.Less(t, time.Since(start), 10*time.Second)
+	require.Equal(t, AppdStopped, appdInstance.Pid())
+}
+
+// TestStopWithTimeout_ProcessAlreadyExited ensures that stopping a process which
+// exited on its own just before Stop does not return an error.
+func TestStopWithTimeout_ProcessAlreadyExited(t *testing.T) {
+	mockBinary := createMockExecutable(t, "exit 0")
+	defer os.Remove(mockBinary) // Cleanup after test
+
+	appdInstance := &Appd{
+		path:   mockBinary,
+		stdin:  os.Stdin,
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+		pid:    AppdStopped,
+	}
+
+	require.NoError(t, appdInstance.Start())
+	pid := appdInstance.Pid()
+	<-appdInstance.exited
+
+	// simulate Stop racing with the process exit
+	appdInstance.pid = pid
+	require.NoError(t, appdInstance.StopWithTimeout(time.Second))
+	require.Equal(t, AppdStopped, appdInstance.Pid())
+}
+
+// TestStopWithTimeout_NotStartedByAppd ensures that StopWithTimeout does not
+// block if there is no exit notification for the process.
+func TestStopWithTimeout_NotStartedByAppd(t *testing.T) {
+	cmd := exec.Command("sleep", "30")
+	require.NoError(t, cmd.Start())
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+
+	appdInstance := &Appd{pid: cmd.Process.Pid}
+	require.NoError(t, appdInstance.StopWithTimeout(time.Second))
+	require.Equal(t, AppdStopped, appdInstance.Pid())
+}
+
 // TestStart_InvalidBinary ensures that the appd instance errors out if the binary does not exist.
 func TestStart_InvalidBinary(t *testing.T) {
 	appdInstance := &Appd{
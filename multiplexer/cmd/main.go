@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"context"
+
 	"github.com/cosmos/cosmos-sdk/client"
 	"github.com/cosmos/cosmos-sdk/server"
 	"github.com/cosmos/cosmos-sdk/server/types"
@@ -11,8 +13,19 @@ import (
 // StartCommandHandler is the type that must implement the multiplexer to match Cosmos SDK start logic.
 type StartCommandHandler = func(svrCtx *server.Context, clientCtx client.Context, appCreator types.AppCreator, withCmt bool, opts server.StartCmdOptions) error
 
+// Option configures the multiplexer created by the start command handler.
+type Option func(mp *abci.Multiplexer)
+
+// WithReadinessCheck registers the multiplexer handover state as a readiness
+// check using the provided registration function.
+func WithReadinessCheck(addCheck func(name string, check func(context.Context) error)) Option {
+	return func(mp *abci.Multiplexer) {
+		addCheck("multiplexer", mp.Ready)
+	}
+}
+
 // New creates a command start handler to use in the Cosmos SDK server start options.
-func New(versions abci.Versions) StartCommandHandler {
+func New(versions abci.Versions, opts ...Option) StartCommandHandler {
 	return func(
 		svrCtx *server.Context,
 		clientCtx client.Context,
@@ -25,6 +38,6 @@ func New(versions abci.Versions) StartCommandHandler {
 			return nil
 		}
 
-		return start(versions, svrCtx, clientCtx, appCreator)
+		return start(versions, svrCtx, clientCtx, appCreator, opts...)
 	}
 }
@@ -19,7 +19,7 @@ import (
 	"github.com/celestiaorg/celestia-app/v4/multiplexer/internal"
 )
 
-func start(versions abci.Versions, svrCtx *server.Context, clientCtx client.Context, appCreator types.AppCreator) error {
+func start(versions abci.Versions, svrCtx *server.Context, clientCtx client.Context, appCreator types.AppCreator, opts ...Option) error {
 	svrCfg, err := getAndValidateConfig(svrCtx)
 	if err != nil {
 		return err
@@ -37,6 +37,10 @@ func start(versions abci.Versions, svrCtx *server.Context, clientCtx client.Cont
 		return err
 	}
 
+	for _, opt := range opts {
+		opt(mp)
+	}
+
 	defer func() {
 		if err := mp.Cleanup(); err != nil {
 			svrCtx.Logger.Error("failed to cleanup multiplexer", "err", err)
@@ -0,0 +1,78 @@
+package testnode
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"cosmossdk.io/log"
+	cmtdbm "github.com/cometbft/cometbft-db"
+	"github.com/cometbft/cometbft/store"
+	dbm "github.com/cosmos/cosmos-db"
+	"github.com/cosmos/cosmos-sdk/server"
+	srvtypes "github.com/cosmos/cosmos-sdk/server/types"
+	simtestutil "github.com/cosmos/cosmos-sdk/testutil/sims"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/celestia-app/v4/app"
+	"github.com/celestiaorg/celestia-app/v4/test/util/genesis"
+)
+
+// TestPebbleDB runs a node whose application and block stores use PebbleDB
+// and verifies that both stores can be reopened once the node has stopped.
+func TestPebbleDB(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping pebbledb integration test in short mode.")
+	}
+
+	tmCfg := DefaultTendermintConfig()
+	tmCfg.DBBackend = string(dbm.PebbleDBBackend)
+
+	cfg := DefaultConfig().
+		WithTendermintConfig(tmCfg).
+		WithAppCreator(func(_ log.Logger, db dbm.DB, _ io.Writer, appOptions srvtypes.AppOptions) srvtypes.Application {
+			return app.New(
+				log.NewNopLogger(),
+				db,
+				nil, // trace store
+				appOptions.Get(TimeoutCommitFlag).(time.Duration), // timeout commit
+				simtestutil.EmptyAppOptions{},
+				server.DefaultBaseappOptions(appOptions)...,
+			)
+		})
+
+	const minHeight = 3
+
+	// The node is started directly instead of via NewNetwork because the
+	// network cleanup removes the data directory.
+	baseDir := filepath.Join(t.TempDir(), "testnode")
+	require.NoError(t, genesis.InitFiles(baseDir, cfg.TmConfig, cfg.AppConfig, cfg.Genesis, 0))
+
+	cometNode, application, err := NewCometNode(baseDir, &cfg.UniversalTestingConfig)
+	require.NoError(t, err)
+	require.NoError(t, cometNode.Start())
+	require.Eventually(t, func() bool {
+		return cometNode.BlockStore().Height() >= minHeight
+	}, time.Minute, 100*time.Millisecond)
+
+	// Stopping the node closes the block store and closing the application
+	// closes the application database.
+	require.NoError(t, cometNode.Stop())
+	cometNode.Wait()
+	require.NoError(t, application.Close())
+
+	dataDir := filepath.Join(cfg.TmConfig.RootDir, "data")
+
+	db, err := dbm.NewDB("application", dbm.PebbleDBBackend, dataDir)
+	require.NoError(t, err)
+	defer db.Close()
+	reopened := app.New(log.NewNopLogger(), db, nil, 0, simtestutil.EmptyAppOptions{})
+	require.GreaterOrEqual(t, reopened.LastBlockHeight(), int64(minHeight))
+
+	blockStoreDB, err := cmtdbm.NewDB("blockstore", cmtdbm.PebbleDBBackend, dataDir)
+	require.NoError(t, err)
+	blockStore := store.NewBlockStore(blockStoreDB)
+	defer blockStore.Close()
+	require.GreaterOrEqual(t, blockStore.Height(), int64(minHeight))
+}